@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/qoder/qoder-github-mcp-server/internal/qmcp"
+	"github.com/qoder/qoder-github-mcp-server/pkg/fixlink"
+	"github.com/qoder/qoder-github-mcp-server/pkg/qoder"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -55,17 +58,69 @@ var (
 				Repo:      parts[1],
 				RunID:     runID,
 				ServerURL: serverURL,
+				Footer: qoder.FooterConfig{
+					Template:      viper.GetString("footer_template"),
+					DisabledTools: strings.Split(viper.GetString("footer_disabled_tools"), ","),
+
+					FixLinkEndpoint: viper.GetString("fix_link_endpoint"),
+					FixLinkSecret:   viper.GetString("fix_link_secret"),
+					FixLinkMaxBytes: viper.GetInt("fix_link_max_bytes"),
+				},
 			}
 			return qmcp.RunStdioServer(stdioServerConfig)
 		},
 	}
+
+	fixLinkCmd = &cobra.Command{
+		Use:   "fix-link",
+		Short: "Work with One-Click Qoder Fix links",
+	}
+
+	fixLinkDecodeCmd = &cobra.Command{
+		Use:   "decode <link-or-token>",
+		Short: "Verify a fix link and print its context",
+		Long:  `Verify the signature of a One-Click Qoder Fix link, or of the bare token from its context parameter, and print the review comment context as JSON.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret := viper.GetString("fix_link_secret")
+			if secret == "" {
+				return errors.New("QODER_FIX_LINK_SECRET not set")
+			}
+
+			// The size limit only applies to encoding, links of any configured size decode
+			signer, err := fixlink.NewSigner("", secret, 0)
+			if err != nil {
+				return err
+			}
+
+			fixContext, err := signer.Decode(args[0])
+			if err != nil {
+				return err
+			}
+
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(fixContext)
+		},
+	}
 )
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.SetVersionTemplate("{{.Short}}\n{{.Version}}\n")
+	stdioCmd.Flags().String("footer-template", "", "Go text/template of the footer appended to posted comments")
+	stdioCmd.Flags().String("footer-disabled-tools", "", "Comma-separated tools that post without a footer, * for all tools")
+	_ = viper.BindPFlag("footer_template", stdioCmd.Flags().Lookup("footer-template"))
+	_ = viper.BindPFlag("footer_disabled_tools", stdioCmd.Flags().Lookup("footer-disabled-tools"))
+	stdioCmd.Flags().String("fix-link-endpoint", "", "Endpoint of One-Click Qoder Fix links, empty disables them")
+	stdioCmd.Flags().Int("fix-link-max-bytes", 0, "Maximum length of a fix link's encoded context (default 4096)")
+	_ = viper.BindPFlag("fix_link_endpoint", stdioCmd.Flags().Lookup("fix-link-endpoint"))
+	_ = viper.BindPFlag("fix_link_max_bytes", stdioCmd.Flags().Lookup("fix-link-max-bytes"))
 	rootCmd.AddCommand(stdioCmd)
+
+	fixLinkCmd.AddCommand(fixLinkDecodeCmd)
+	rootCmd.AddCommand(fixLinkCmd)
 }
 
 func initConfig() {
@@ -77,6 +132,11 @@ func initConfig() {
 	viper.BindEnv("github_repository", "GITHUB_REPOSITORY")
 	viper.BindEnv("github_run_id", "GITHUB_RUN_ID")
 	viper.BindEnv("github_server_url", "GITHUB_SERVER_URL")
+	viper.BindEnv("footer_template", "QODER_FOOTER_TEMPLATE")
+	viper.BindEnv("footer_disabled_tools", "QODER_FOOTER_DISABLED_TOOLS")
+	viper.BindEnv("fix_link_endpoint", "QODER_FIX_LINK_ENDPOINT")
+	viper.BindEnv("fix_link_secret", "QODER_FIX_LINK_SECRET")
+	viper.BindEnv("fix_link_max_bytes", "QODER_FIX_LINK_MAX_BYTES")
 }
 
 func main() {
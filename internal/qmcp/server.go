@@ -32,6 +32,9 @@ type StdioServerConfig struct {
 
 	// GitHub server URL
 	ServerURL string
+
+	// Footer appended to posted comments
+	Footer qoder.FooterConfig
 }
 
 // RunStdioServer starts the MCP server with stdio transport
@@ -41,7 +44,7 @@ func RunStdioServer(cfg StdioServerConfig) error {
 	defer stop()
 
 	// Create the MCP server
-	qoderServer := qoder.NewServer(cfg.Version, cfg.Token, cfg.Owner, cfg.Repo, cfg.RunID, cfg.ServerURL)
+	qoderServer := qoder.NewServer(cfg.Version, cfg.Token, cfg.Owner, cfg.Repo, cfg.RunID, cfg.ServerURL, cfg.Footer)
 
 	// Create stdio server
 	stdioServer := server.NewStdioServer(qoderServer)
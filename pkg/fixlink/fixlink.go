@@ -0,0 +1,222 @@
+// Package fixlink encodes the context of a review comment into a signed "One-Click Qoder Fix" link
+// and decodes such links back into the context on the IDE side.
+//
+// A link carries its context in the "context" query parameter as a token of the form
+//
+//	v1.<payload>.<signature>
+//
+// where payload is the deflate-compressed JSON context and signature is the HMAC-SHA256 of
+// "v1.<payload>", both base64url-encoded without padding.
+package fixlink
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	// tokenVersion prefixes every token so the format can change later
+	tokenVersion = "v1"
+
+	// contextParam is the query parameter holding the token in a fix link
+	contextParam = "context"
+
+	// DefaultMaxBytes is the default maximum length of a token
+	DefaultMaxBytes = 4096
+
+	// maxTokenBytes bounds the length of a token accepted for decoding, whatever size the link was made with
+	maxTokenBytes = 64 << 10
+
+	// maxDecodedBytes bounds the decompressed size of a token's context
+	maxDecodedBytes = 1 << 20
+)
+
+var (
+	// ErrTooLarge is returned when a context does not fit in the maximum token size
+	ErrTooLarge = errors.New("fix link context is too large")
+
+	// ErrInvalidSignature is returned when a token was not signed with the expected secret
+	ErrInvalidSignature = errors.New("fix link signature is invalid")
+
+	// ErrMalformed is returned when a link or token cannot be parsed
+	ErrMalformed = errors.New("fix link is malformed")
+)
+
+// Context holds the context for a one-click Qoder fix
+type Context struct {
+	Owner       string `json:"owner"`
+	Repo        string `json:"repo"`
+	PullNumber  int    `json:"pull_number"`
+	CommitID    string `json:"commit_id"`
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	Side        string `json:"side"`
+	StartLine   int    `json:"start_line,omitempty"`
+	StartSide   string `json:"start_side,omitempty"`
+	Body        string `json:"body"`
+	BodyOmitted bool   `json:"body_omitted,omitempty"` // Body was dropped to fit the size limit
+}
+
+// Signer creates and verifies fix links
+type Signer struct {
+	endpoint string
+	secret   []byte
+	maxBytes int
+}
+
+// NewSigner creates a signer for links to endpoint, signed with secret
+// maxBytes limits the length of the contexts it encodes; 0 uses DefaultMaxBytes
+func NewSigner(endpoint, secret string, maxBytes int) (*Signer, error) {
+	if secret == "" {
+		return nil, errors.New("fix link secret is required")
+	}
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid fix link endpoint %q", endpoint)
+		}
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	return &Signer{endpoint: endpoint, secret: []byte(secret), maxBytes: maxBytes}, nil
+}
+
+// Link builds the fix link for a context
+// If the context is too large, the comment body is left out; ErrTooLarge is returned if it still does not fit
+func (s *Signer) Link(ctx Context) (string, error) {
+	if s.endpoint == "" {
+		return "", errors.New("fix link endpoint is not configured")
+	}
+
+	token, err := s.Encode(ctx)
+	if errors.Is(err, ErrTooLarge) && ctx.Body != "" {
+		ctx.Body = ""
+		ctx.BodyOmitted = true
+		token, err = s.Encode(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid fix link endpoint %q", s.endpoint)
+	}
+	query := u.Query()
+	query.Set(contextParam, token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Encode encodes and signs a context into a token
+func (s *Signer) Encode(ctx Context) (string, error) {
+	contextJSON, err := json.Marshal(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal fix context: %w", err)
+	}
+
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(contextJSON); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	signed := tokenVersion + "." + base64.RawURLEncoding.EncodeToString(compressed.Bytes())
+	token := signed + "." + base64.RawURLEncoding.EncodeToString(s.sign(signed))
+	if len(token) > s.maxBytes {
+		return "", fmt.Errorf("%w: %d bytes, limit is %d", ErrTooLarge, len(token), s.maxBytes)
+	}
+	return token, nil
+}
+
+// Decode verifies a fix link, or a bare token, and returns its context
+func (s *Signer) Decode(link string) (Context, error) {
+	token, err := TokenFromLink(link)
+	if err != nil {
+		return Context{}, err
+	}
+	// Links made with a larger size limit are still valid, so only a sanity cap applies here
+	if len(token) > maxTokenBytes {
+		return Context{}, fmt.Errorf("%w: %d bytes, limit is %d", ErrTooLarge, len(token), maxTokenBytes)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Context{}, fmt.Errorf("%w: expected 3 token parts, got %d", ErrMalformed, len(parts))
+	}
+	if parts[0] != tokenVersion {
+		return Context{}, fmt.Errorf("%w: unsupported version %q", ErrMalformed, parts[0])
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Context{}, fmt.Errorf("%w: invalid signature encoding", ErrMalformed)
+	}
+	if !hmac.Equal(signature, s.sign(parts[0]+"."+parts[1])) {
+		return Context{}, ErrInvalidSignature
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Context{}, fmt.Errorf("%w: invalid payload encoding", ErrMalformed)
+	}
+
+	// Read one byte past the limit to tell a payload at the limit from one beyond it
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+	contextJSON, err := io.ReadAll(io.LimitReader(reader, maxDecodedBytes+1))
+	if err != nil {
+		return Context{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(contextJSON) > maxDecodedBytes {
+		return Context{}, fmt.Errorf("%w: decoded context exceeds %d bytes", ErrTooLarge, maxDecodedBytes)
+	}
+
+	var ctx Context
+	if err := json.Unmarshal(contextJSON, &ctx); err != nil {
+		return Context{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return ctx, nil
+}
+
+// sign returns the HMAC-SHA256 of data
+func (s *Signer) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// TokenFromLink extracts the token from a fix link; a bare token is returned unchanged
+func TokenFromLink(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if strings.HasPrefix(link, tokenVersion+".") {
+		return link, nil
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	token := u.Query().Get(contextParam)
+	if token == "" {
+		return "", fmt.Errorf("%w: no %s parameter", ErrMalformed, contextParam)
+	}
+	return token, nil
+}
@@ -0,0 +1,155 @@
+package fixlink
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLinkRoundTrip(t *testing.T) {
+	signer, err := NewSigner("https://fix.example.com/reload-to-qoder", "secret", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := Context{
+		Owner:      "octo",
+		Repo:       "repo",
+		PullNumber: 7,
+		CommitID:   "abc123",
+		Path:       "main.go",
+		Line:       12,
+		Side:       "RIGHT",
+		Body:       "Missing error check\n\n```suggestion\nif err != nil {\n\treturn err\n}\n```",
+	}
+
+	link, err := signer.Link(ctx)
+	if err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	if !strings.HasPrefix(link, "https://fix.example.com/reload-to-qoder?context=v1.") {
+		t.Errorf("unexpected link %q", link)
+	}
+
+	decoded, err := signer.Decode(link)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if decoded != ctx {
+		t.Errorf("Decode() = %+v, expected %+v", decoded, ctx)
+	}
+
+	// A bare token decodes as well
+	token, _ := TokenFromLink(link)
+	if decoded, err := signer.Decode(token); err != nil || decoded != ctx {
+		t.Errorf("Decode(token) = %+v, %v", decoded, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	signer, _ := NewSigner("https://fix.example.com/fix", "secret", 0)
+	otherSigner, _ := NewSigner("https://fix.example.com/fix", "other-secret", 0)
+
+	link, err := signer.Link(Context{Owner: "octo", Repo: "repo", Body: "text"})
+	if err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	token, _ := TokenFromLink(link)
+	parts := strings.Split(token, ".")
+
+	testCases := []struct {
+		name        string
+		link        string
+		expectedErr error
+	}{
+		{name: "wrong secret", link: link, expectedErr: ErrInvalidSignature},
+		{name: "tampered payload", link: "v1." + parts[1] + "A." + parts[2], expectedErr: ErrInvalidSignature},
+		{name: "missing parameter", link: "https://fix.example.com/fix?other=1", expectedErr: ErrMalformed},
+		{name: "too few parts", link: "v1." + parts[1], expectedErr: ErrMalformed},
+		{name: "unknown version", link: "https://fix.example.com/fix?context=" + url.QueryEscape("v2."+parts[1]+"."+parts[2]), expectedErr: ErrMalformed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decoder := signer
+			if tc.name == "wrong secret" {
+				decoder = otherSigner
+			}
+			if _, err := decoder.Decode(tc.link); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Decode() error = %v, expected %v", err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestLinkSizeLimit(t *testing.T) {
+	signer, _ := NewSigner("https://fix.example.com/fix", "secret", 300)
+
+	// Random-looking text does not compress, so the body cannot fit
+	var sb strings.Builder
+	seed := uint32(1)
+	for i := 0; i < 400; i++ {
+		seed = seed*1664525 + 1013904223
+		sb.WriteByte(byte('a' + (seed>>24)%26))
+	}
+	ctx := Context{Owner: "octo", Repo: "repo", PullNumber: 1, Path: "main.go", Body: sb.String()}
+
+	link, err := signer.Link(ctx)
+	if err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	decoded, err := signer.Decode(link)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if decoded.Body != "" || !decoded.BodyOmitted {
+		t.Errorf("expected body to be omitted, got %+v", decoded)
+	}
+
+	tiny, _ := NewSigner("https://fix.example.com/fix", "secret", 10)
+	if _, err := tiny.Link(ctx); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+
+	// The size limit applies to encoding; a decoder with a smaller one still accepts the link
+	large, _ := NewSigner("https://fix.example.com/fix", "secret", 8192)
+	link, err = large.Link(ctx)
+	if err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	decoded, err = tiny.Decode(link)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if decoded.Body != ctx.Body {
+		t.Errorf("expected the full body, got %+v", decoded)
+	}
+
+	if _, err := signer.Decode("v1." + strings.Repeat("a", maxTokenBytes) + ".sig"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for an oversized token, got %v", err)
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	testCases := []struct {
+		name        string
+		endpoint    string
+		secret      string
+		expectError bool
+	}{
+		{name: "valid", endpoint: "https://fix.example.com/fix", secret: "secret"},
+		{name: "decode only", endpoint: "", secret: "secret"},
+		{name: "missing secret", endpoint: "https://fix.example.com/fix", secret: "", expectError: true},
+		{name: "relative endpoint", endpoint: "/fix", secret: "secret", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSigner(tc.endpoint, tc.secret, 0)
+			if (err != nil) != tc.expectError {
+				t.Errorf("NewSigner() error = %v, expectError %v", err, tc.expectError)
+			}
+		})
+	}
+}
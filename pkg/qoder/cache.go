@@ -0,0 +1,405 @@
+package qoder
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheMaxBytes bounds the memory used by the in-memory response cache
+	defaultCacheMaxBytes = 64 << 20
+
+	// maxCachedBodyBytes is the largest response body that is cached
+	maxCachedBodyBytes = 10 << 20
+)
+
+// cachedResponse is a GitHub response stored for conditional requests
+type cachedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+}
+
+// size estimates the memory used by a cached response
+func (c *cachedResponse) size() int {
+	n := len(c.Body) + len(c.ETag) + len(c.LastModified)
+	for key, values := range c.Header {
+		n += len(key)
+		for _, value := range values {
+			n += len(value)
+		}
+	}
+	return n
+}
+
+// responseCache stores responses by request key
+type responseCache interface {
+	get(key string) (*cachedResponse, bool)
+	set(key string, response *cachedResponse)
+}
+
+// ====== Memory Cache ======
+
+// memoryCache is a size-bounded, least recently used response cache
+type memoryCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	order    *list.List // Front is the most recently used
+	entries  map[string]*list.Element
+}
+
+// memoryCacheEntry is an element of the memory cache's usage list
+type memoryCacheEntry struct {
+	key      string
+	response *cachedResponse
+}
+
+// newMemoryCache creates a memory cache holding at most maxBytes of responses
+func newMemoryCache(maxBytes int) *memoryCache {
+	return &memoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+func (c *memoryCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).response, true
+}
+
+func (c *memoryCache) set(key string, response *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.bytes -= element.Value.(*memoryCacheEntry).response.size()
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	if response.size() > c.maxBytes {
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, response: response})
+	c.bytes += response.size()
+
+	// Evict the least recently used responses until the cache fits
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*memoryCacheEntry)
+		c.bytes -= entry.response.size()
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+	}
+}
+
+// ====== Disk Cache ======
+
+// diskCache stores responses as JSON files in a directory, so they survive across sessions
+// A memory cache in front of it keeps repeated reads off the disk. The files are bounded in total size
+// like the memory cache; the least recently used ones, by modification time, are removed first.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+	memory   *memoryCache
+
+	mu    sync.Mutex
+	bytes int64 // Total size of the entry files
+}
+
+// diskCacheFile is an entry file of the disk cache
+type diskCacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// newDiskCache creates a disk cache in dir holding at most maxBytes of entries, creating the directory
+// if needed
+func newDiskCache(dir string, maxBytes int, memory *memoryCache) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &diskCache{dir: dir, maxBytes: int64(maxBytes), memory: memory}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, file := range c.files() {
+		c.bytes += file.size
+	}
+	// The limit may be lower than in a previous session
+	c.evict()
+	return c, nil
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// files lists the entry files in the cache directory
+func (c *diskCache) files() []diskCacheFile {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []diskCacheFile
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != ".json" {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, diskCacheFile{path: filepath.Join(c.dir, dirEntry.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files
+}
+
+// evict removes the least recently used entry files until the cache fits; c.mu must be held
+// The directory is listed again, so entries written by other sessions sharing it are counted too
+func (c *diskCache) evict() {
+	if c.bytes <= c.maxBytes {
+		return
+	}
+
+	files := c.files()
+	c.bytes = 0
+	for _, file := range files {
+		c.bytes += file.size
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files {
+		if c.bytes <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err == nil || os.IsNotExist(err) {
+			c.bytes -= file.size
+		}
+	}
+}
+
+func (c *diskCache) get(key string) (*cachedResponse, bool) {
+	if response, ok := c.memory.get(key); ok {
+		c.touch(key)
+		return response, true
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var response cachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+
+	c.touch(key)
+	c.memory.set(key, &response)
+	return &response, true
+}
+
+// touch marks an entry file as recently used
+func (c *diskCache) touch(key string) {
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+}
+
+func (c *diskCache) set(key string, response *cachedResponse) {
+	c.memory.set(key, response)
+
+	data, err := json.Marshal(response)
+	if err != nil || int64(len(data)) > c.maxBytes {
+		return
+	}
+
+	// Write to a temporary file first so a concurrent reader never sees a partial entry
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write HTTP cache entry: %v\n", err)
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var replaced int64
+	if info, err := os.Stat(c.path(key)); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	c.bytes += int64(len(data)) - replaced
+	c.evict()
+}
+
+// ====== Cache Transport ======
+
+// cacheTransport makes GET requests conditional with the ETag or Last-Modified of a cached response and
+// serves the cached body when GitHub answers 304 Not Modified, which does not count against the rate limit
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache responseCache
+}
+
+// newCacheTransportFromEnv creates a cache transport configured by the environment
+// QODER_HTTP_CACHE_DISABLED=true turns caching off and returns base unchanged; QODER_HTTP_CACHE_DIR also
+// stores responses on disk; QODER_HTTP_CACHE_MAX_BYTES bounds the memory cache and the disk cache each
+func newCacheTransportFromEnv(base http.RoundTripper) http.RoundTripper {
+	if disabled, _ := strconv.ParseBool(os.Getenv("QODER_HTTP_CACHE_DISABLED")); disabled {
+		return base
+	}
+
+	maxBytes := defaultCacheMaxBytes
+	if value := os.Getenv("QODER_HTTP_CACHE_MAX_BYTES"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			maxBytes = n
+		}
+	}
+	memory := newMemoryCache(maxBytes)
+
+	var cache responseCache = memory
+	if dir := os.Getenv("QODER_HTTP_CACHE_DIR"); dir != "" {
+		disk, err := newDiskCache(dir, maxBytes, memory)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to use QODER_HTTP_CACHE_DIR, caching in memory only: %v\n", err)
+		} else {
+			cache = disk
+		}
+	}
+
+	return &cacheTransport{base: base, cache: cache}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	cached, hit := t.cache.get(key)
+
+	if hit {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if hit && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cached.toResponse(req, resp.Header), nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+	if resp.ContentLength > maxCachedBodyBytes {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedBodyBytes {
+		// Too large to cache, hand back the rest of the body untouched
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.set(key, &cachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		ETag:         etag,
+		LastModified: lastModified,
+	})
+	return resp, nil
+}
+
+// toResponse rebuilds the cached response for a request, with the rate limit headers of the 304 response
+func (c *cachedResponse) toResponse(req *http.Request, notModifiedHeader http.Header) *http.Response {
+	header := c.Header.Clone()
+	for _, key := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Used", "X-RateLimit-Resource"} {
+		if value := notModifiedHeader.Get(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+	header.Set("X-From-Cache", "1")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// cacheKey identifies a request by URL, representation and credentials, so responses are never shared
+// between tokens or between e.g. the JSON and diff media types of the same URL
+func cacheKey(req *http.Request) string {
+	hash := sha256.New()
+	for _, part := range []string{req.URL.String(), req.Header.Get("Accept"), req.Header.Get("Authorization")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readCloser combines a reader with the closer of the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,189 @@
+package qoder
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// etagServer is a stub GitHub endpoint that answers conditional requests
+type etagServer struct {
+	etag     string
+	body     string
+	requests []*http.Request
+}
+
+func (s *etagServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, req)
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req, Body: io.NopCloser(strings.NewReader(""))}
+	resp.Header.Set("X-RateLimit-Limit", "5000")
+	resp.Header.Set("X-RateLimit-Remaining", "4999")
+	if req.Header.Get("If-None-Match") == s.etag {
+		resp.StatusCode = http.StatusNotModified
+		return resp, nil
+	}
+	resp.Header.Set("ETag", s.etag)
+	resp.Body = io.NopCloser(strings.NewReader(s.body))
+	return resp, nil
+}
+
+func TestCacheTransport(t *testing.T) {
+	testCases := []struct {
+		name string
+		dir  bool
+	}{
+		{name: "memory"},
+		{name: "disk", dir: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cache responseCache = newMemoryCache(defaultCacheMaxBytes)
+			if tc.dir {
+				disk, err := newDiskCache(t.TempDir(), defaultCacheMaxBytes, newMemoryCache(defaultCacheMaxBytes))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				cache = disk
+			}
+
+			origin := &etagServer{etag: `"v1"`, body: `{"number":1}`}
+			transport := &cacheTransport{base: origin, cache: cache}
+
+			get := func(accept string) (*http.Response, string) {
+				req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/o/r/pulls/1", nil)
+				req.Header.Set("Accept", accept)
+				resp, err := transport.RoundTrip(req)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				return resp, string(body)
+			}
+
+			// First request fills the cache
+			resp, body := get("application/json")
+			if resp.StatusCode != http.StatusOK || body != `{"number":1}` {
+				t.Fatalf("first request: %d %q", resp.StatusCode, body)
+			}
+			if origin.requests[0].Header.Get("If-None-Match") != "" {
+				t.Error("first request should not be conditional")
+			}
+
+			// Second request is conditional and served from the cache
+			resp, body = get("application/json")
+			if got := origin.requests[1].Header.Get("If-None-Match"); got != `"v1"` {
+				t.Errorf("If-None-Match = %q, expected %q", got, `"v1"`)
+			}
+			if resp.StatusCode != http.StatusOK || body != `{"number":1}` || resp.Header.Get("X-From-Cache") != "1" {
+				t.Errorf("cached request: %d %q from cache %q", resp.StatusCode, body, resp.Header.Get("X-From-Cache"))
+			}
+
+			// Another media type of the same URL is cached separately
+			get("application/vnd.github.v3.diff")
+			if got := origin.requests[2].Header.Get("If-None-Match"); got != "" {
+				t.Errorf("diff request should not reuse the JSON ETag, got %q", got)
+			}
+
+			// A changed resource replaces the cached response
+			origin.etag, origin.body = `"v2"`, `{"number":2}`
+			_, body = get("application/json")
+			if body != `{"number":2}` {
+				t.Errorf("changed resource: got %q", body)
+			}
+			_, body = get("application/json")
+			if body != `{"number":2}` || origin.requests[4].Header.Get("If-None-Match") != `"v2"` {
+				t.Errorf("changed resource not cached: got %q", body)
+			}
+		})
+	}
+}
+
+func TestCacheTransportSkipsWrites(t *testing.T) {
+	origin := &etagServer{etag: `"v1"`, body: `{}`}
+	cache := newMemoryCache(defaultCacheMaxBytes)
+	transport := &cacheTransport{base: origin, cache: cache}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/repos/o/r/pulls/1/reviews", strings.NewReader(`{}`))
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("POST response should not be cached")
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := newMemoryCache(100)
+	entry := func(n int) *cachedResponse {
+		return &cachedResponse{StatusCode: 200, Body: []byte(strings.Repeat("x", n))}
+	}
+
+	cache.set("a", entry(40))
+	cache.set("b", entry(40))
+	cache.get("a") // "b" is now the least recently used
+	cache.set("c", entry(40))
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+
+	cache.set("huge", entry(200))
+	if _, ok := cache.get("huge"); ok {
+		t.Error("entries larger than the cache should not be stored")
+	}
+	if cache.bytes > 100 {
+		t.Errorf("cache holds %d bytes, limit is 100", cache.bytes)
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	entry := &cachedResponse{StatusCode: 200, Body: []byte(strings.Repeat("x", 100))}
+	data, _ := json.Marshal(entry)
+	size := len(data)
+
+	dir := t.TempDir()
+	cache, err := newDiskCache(dir, size*2+size/2, newMemoryCache(defaultCacheMaxBytes))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.set("a", entry)
+	cache.set("b", entry)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.path("a"), past, past)
+	os.Chtimes(cache.path("b"), past.Add(time.Minute), past.Add(time.Minute))
+	cache.get("a") // "b" is now the least recently used
+	cache.set("c", entry)
+
+	if _, err := os.Stat(cache.path("b")); !os.IsNotExist(err) {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := os.Stat(cache.path(key)); err != nil {
+			t.Errorf("expected %s to be on disk: %v", key, err)
+		}
+	}
+	if cache.bytes > int64(size*2+size/2) {
+		t.Errorf("cache holds %d bytes, limit is %d", cache.bytes, size*2+size/2)
+	}
+
+	// A new session with a lower limit trims the directory
+	reopened, err := newDiskCache(dir, size, newMemoryCache(defaultCacheMaxBytes))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if files := reopened.files(); len(files) != 1 || reopened.bytes != int64(size) {
+		t.Errorf("expected 1 entry of %d bytes after reopening, got %d entries and %d bytes", size, len(files), reopened.bytes)
+	}
+}
@@ -0,0 +1,485 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxAnnotationsPerRequest is the number of annotations the Checks API accepts per request
+const maxAnnotationsPerRequest = 50
+
+// annotationLevels are the annotation levels of the Checks API, most severe first
+var annotationLevels = []string{"failure", "warning", "notice"}
+
+// checkAnnotationParams is an annotation as given to the check run tools
+type checkAnnotationParams struct {
+	Path        string `mapstructure:"path"`
+	StartLine   int    `mapstructure:"start_line"`
+	EndLine     int    `mapstructure:"end_line"`
+	StartColumn int    `mapstructure:"start_column"`
+	EndColumn   int    `mapstructure:"end_column"`
+	Level       string `mapstructure:"level"`
+	Message     string `mapstructure:"message"`
+	Title       string `mapstructure:"title"`
+	RawDetails  string `mapstructure:"raw_details"`
+}
+
+// checkRunParams are the parameters shared by the check run tools
+type checkRunParams struct {
+	Name        string                  `mapstructure:"name"`
+	Status      string                  `mapstructure:"status"`
+	Conclusion  string                  `mapstructure:"conclusion"`
+	Title       string                  `mapstructure:"title"`
+	Summary     string                  `mapstructure:"summary"`
+	Text        string                  `mapstructure:"text"`
+	DetailsURL  string                  `mapstructure:"details_url"`
+	Annotations []checkAnnotationParams `mapstructure:"annotations"`
+}
+
+// checkRunToolOptions are the tool options shared by the check run tools
+func checkRunToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("status",
+			mcp.Description("Status of the check run (default: completed when a conclusion is given, otherwise in_progress)"),
+			mcp.Enum("queued", "in_progress", "completed"),
+		),
+		mcp.WithString("conclusion",
+			mcp.Description("Final conclusion, required when the status is completed"),
+			mcp.Enum("success", "failure", "neutral", "cancelled", "skipped", "timed_out", "action_required"),
+		),
+		mcp.WithString("title", mcp.Description("Title of the check run output")),
+		mcp.WithString("summary", mcp.Description("Summary of the check run output, Markdown supported (default: annotation counts by level)")),
+		mcp.WithString("text", mcp.Description("Details of the check run output, Markdown supported")),
+		mcp.WithString("details_url", mcp.Description("URL with the full details of the check (default: the current GitHub Actions run)")),
+		mcp.WithArray("annotations",
+			mcp.Items(
+				map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"path", "start_line", "message"},
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "path of the file, relative to the repository root",
+						},
+						"start_line": map[string]interface{}{
+							"type":        "number",
+							"description": "first line of the annotation",
+						},
+						"end_line": map[string]interface{}{
+							"type":        "number",
+							"description": "last line of the annotation (default: start_line)",
+						},
+						"start_column": map[string]interface{}{
+							"type":        "number",
+							"description": "first column, only when the annotation is on a single line",
+						},
+						"end_column": map[string]interface{}{
+							"type":        "number",
+							"description": "last column, only when the annotation is on a single line",
+						},
+						"level": map[string]interface{}{
+							"type":        "string",
+							"enum":        annotationLevels,
+							"description": "annotation level (default: warning)",
+						},
+						"message": map[string]interface{}{
+							"type":        "string",
+							"description": "short description of the finding",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "title of the annotation",
+						},
+						"raw_details": map[string]interface{}{
+							"type":        "string",
+							"description": "details of the finding, shown when the annotation is expanded",
+						},
+					},
+				}),
+			mcp.Description("Annotations to attach to the check run. Any number is accepted; they are sent 50 at a time"),
+		),
+	}
+}
+
+// newCheckAnnotations validates annotation parameters and converts them to API annotations
+func newCheckAnnotations(params []checkAnnotationParams) ([]*github.CheckRunAnnotation, error) {
+	annotations := make([]*github.CheckRunAnnotation, 0, len(params))
+	for i, p := range params {
+		if p.Path == "" {
+			return nil, fmt.Errorf("annotations[%d]: path is required", i)
+		}
+		if p.Message == "" {
+			return nil, fmt.Errorf("annotations[%d]: message is required", i)
+		}
+		if p.StartLine < 1 {
+			return nil, fmt.Errorf("annotations[%d]: start_line must be a positive line number", i)
+		}
+		if p.EndLine == 0 {
+			p.EndLine = p.StartLine
+		}
+		if p.EndLine < p.StartLine {
+			return nil, fmt.Errorf("annotations[%d]: end_line %d is before start_line %d", i, p.EndLine, p.StartLine)
+		}
+		if p.Level == "" {
+			p.Level = "warning"
+		}
+		if !slices.Contains(annotationLevels, p.Level) {
+			return nil, fmt.Errorf("annotations[%d]: level must be one of: %s", i, strings.Join(annotationLevels, ", "))
+		}
+
+		annotation := &github.CheckRunAnnotation{
+			Path:            github.Ptr(p.Path),
+			StartLine:       github.Ptr(p.StartLine),
+			EndLine:         github.Ptr(p.EndLine),
+			AnnotationLevel: github.Ptr(p.Level),
+			Message:         github.Ptr(p.Message),
+		}
+		// The API only accepts columns on single-line annotations
+		if p.StartLine == p.EndLine && p.StartColumn > 0 {
+			annotation.StartColumn = github.Ptr(p.StartColumn)
+			if p.EndColumn >= p.StartColumn {
+				annotation.EndColumn = github.Ptr(p.EndColumn)
+			}
+		}
+		if p.Title != "" {
+			annotation.Title = github.Ptr(p.Title)
+		}
+		if p.RawDetails != "" {
+			annotation.RawDetails = github.Ptr(p.RawDetails)
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, nil
+}
+
+// batchAnnotations splits annotations into batches the API accepts in one request
+func batchAnnotations(annotations []*github.CheckRunAnnotation) [][]*github.CheckRunAnnotation {
+	var batches [][]*github.CheckRunAnnotation
+	for len(annotations) > 0 {
+		n := min(len(annotations), maxAnnotationsPerRequest)
+		batches = append(batches, annotations[:n])
+		annotations = annotations[n:]
+	}
+	return batches
+}
+
+// annotationSummary describes annotations by level, e.g. "2 failures, 1 warning"
+func annotationSummary(annotations []*github.CheckRunAnnotation) string {
+	if len(annotations) == 0 {
+		return "No findings."
+	}
+
+	counts := map[string]int{}
+	for _, annotation := range annotations {
+		counts[annotation.GetAnnotationLevel()]++
+	}
+
+	var parts []string
+	for _, level := range annotationLevels {
+		if n := counts[level]; n > 0 {
+			if n > 1 {
+				parts = append(parts, fmt.Sprintf("%d %ss", n, level))
+			} else {
+				parts = append(parts, fmt.Sprintf("%d %s", n, level))
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkRunState fills in the status from the conclusion and checks that they agree
+// An empty status is completed when there is a conclusion, and keepStatus otherwise
+func checkRunState(status, conclusion, keepStatus string) (string, error) {
+	if status == "" {
+		status = keepStatus
+		if conclusion != "" {
+			status = "completed"
+		}
+	}
+	if status == "completed" && conclusion == "" {
+		return "", fmt.Errorf("a conclusion is required when the status is completed")
+	}
+	if status != "completed" && conclusion != "" {
+		return "", fmt.Errorf("a conclusion can only be set when the status is completed")
+	}
+	return status, nil
+}
+
+// checkRunOutput builds the output of a check run
+// The API requires a title and summary whenever output is sent
+func checkRunOutput(title, summary, text string, annotations []*github.CheckRunAnnotation) *github.CheckRunOutput {
+	if title == "" && summary == "" && text == "" && len(annotations) == 0 {
+		return nil
+	}
+	if summary == "" {
+		summary = annotationSummary(annotations)
+	}
+	output := &github.CheckRunOutput{
+		Title:   github.Ptr(title),
+		Summary: github.Ptr(summary),
+	}
+	if text != "" {
+		output.Text = github.Ptr(text)
+	}
+	return output
+}
+
+// updateCheckRunInBatches sends annotation batches to a check run, one update per batch since the API
+// appends the annotations of each update; opts is applied with the last batch, or alone if there is none
+func updateCheckRunInBatches(ctx context.Context, client *github.Client, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions, batches [][]*github.CheckRunAnnotation) (*github.CheckRun, int, error) {
+	requests := 0
+	for i, batch := range batches {
+		update := github.UpdateCheckRunOptions{Name: opts.Name}
+		if i == len(batches)-1 {
+			update = opts
+		}
+		output := *opts.Output
+		output.Annotations = batch
+		update.Output = &output
+
+		checkRun, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, update)
+		requests++
+		if err != nil {
+			return nil, requests, fmt.Errorf("failed to send annotations %d-%d: %w", i*maxAnnotationsPerRequest+1, i*maxAnnotationsPerRequest+len(batch), err)
+		}
+		if i == len(batches)-1 {
+			return checkRun, requests, nil
+		}
+	}
+
+	checkRun, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, opts)
+	requests++
+	if err != nil {
+		return nil, requests, fmt.Errorf("failed to update check run: %w", err)
+	}
+	return checkRun, requests, nil
+}
+
+// checkRunResult is the result of the check run tools
+func checkRunResult(checkRun *github.CheckRun, annotations, requests int) *mcp.CallToolResult {
+	result := map[string]interface{}{
+		"check_run_id": checkRun.GetID(),
+		"name":         checkRun.GetName(),
+		"head_sha":     checkRun.GetHeadSHA(),
+		"status":       checkRun.GetStatus(),
+		"conclusion":   checkRun.GetConclusion(),
+		"url":          checkRun.GetHTMLURL(),
+		"annotations":  annotations,
+		"requests":     requests,
+	}
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON))
+}
+
+// CreateCheckRun creates a tool to create a check run with annotations on a commit
+func CreateCheckRun(getClient GetClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "create_check_run"
+	description := "Create a check run on a commit, shown in the pull request's Checks tab, with annotations on file lines. Use this to publish review findings as CI-style output. Requires a token that can write checks, e.g. the GitHub Actions GITHUB_TOKEN with checks: write."
+
+	options := []mcp.ToolOption{
+		mcp.WithDescription(description),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the check, e.g. 'Qoder Review'")),
+		mcp.WithNumber("pull_number", mcp.Description("Pull request whose head commit gets the check run; required unless head_sha is given")),
+		mcp.WithString("head_sha", mcp.Description("Commit SHA to attach the check run to (default: the pull request's head commit)")),
+	}
+	options = append(options, checkRunToolOptions()...)
+
+	return mcp.NewTool(toolName, options...),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				checkRunParams `mapstructure:",squash"`
+				PullNumber     int    `mapstructure:"pull_number"`
+				HeadSHA        string `mapstructure:"head_sha"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if params.Name == "" {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+			if params.HeadSHA == "" && params.PullNumber == 0 {
+				return mcp.NewToolResultError("either pull_number or head_sha is required"), nil
+			}
+
+			status, err := checkRunState(params.Status, params.Conclusion, "in_progress")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			annotations, err := newCheckAnnotations(params.Annotations)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			headSHA := params.HeadSHA
+			if headSHA == "" {
+				pr, _, err := client.PullRequests.Get(ctx, owner, repo, params.PullNumber)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to get PR: %v", err)), nil
+				}
+				headSHA = pr.GetHead().GetSHA()
+			}
+
+			title := params.Title
+			if title == "" {
+				title = params.Name
+			}
+			output := checkRunOutput(title, params.Summary, params.Text, annotations)
+			batches := batchAnnotations(annotations)
+
+			opts := github.CreateCheckRunOptions{
+				Name:    params.Name,
+				HeadSHA: headSHA,
+				Status:  github.Ptr(status),
+				Output:  output,
+			}
+			detailsURL := params.DetailsURL
+			if detailsURL == "" {
+				detailsURL = footer.RunURL()
+			}
+			if detailsURL != "" {
+				opts.DetailsURL = github.Ptr(detailsURL)
+			}
+			if runID := footer.RunID(); runID != "" {
+				opts.ExternalID = github.Ptr(runID)
+			}
+			// The first batch goes with the create request. With more batches to follow, the check run
+			// stays in progress until the last one, so it never shows as done with part of its annotations.
+			final := github.UpdateCheckRunOptions{Name: params.Name, Output: output}
+			if len(batches) > 0 {
+				first := *output
+				first.Annotations = batches[0]
+				opts.Output = &first
+			}
+			now := &github.Timestamp{Time: time.Now()}
+			if len(batches) > 1 && status == "completed" {
+				opts.Status = github.Ptr("in_progress")
+				final.Status = github.Ptr(status)
+				final.Conclusion = github.Ptr(params.Conclusion)
+				final.CompletedAt = now
+			} else if params.Conclusion != "" {
+				opts.Conclusion = github.Ptr(params.Conclusion)
+				opts.CompletedAt = now
+			}
+			if opts.GetStatus() == "in_progress" {
+				opts.StartedAt = now
+			}
+
+			checkRun, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to create check run: %v", err)), nil
+			}
+			requests := 1
+
+			if len(batches) > 1 {
+				updated, n, err := updateCheckRunInBatches(ctx, client, owner, repo, checkRun.GetID(), final, batches[1:])
+				requests += n
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("check run %d was created, but %v", checkRun.GetID(), err)), nil
+				}
+				checkRun = updated
+			}
+
+			return checkRunResult(checkRun, len(annotations), requests), nil
+		}
+}
+
+// UpdateCheckRun creates a tool to update a check run and add annotations to it
+func UpdateCheckRun(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "update_check_run"
+	description := "Update a check run created with create_check_run: change its status or conclusion, replace its output and append annotations. Annotations are added to the ones the check run already has."
+
+	options := []mcp.ToolOption{
+		mcp.WithDescription(description),
+		mcp.WithNumber("check_run_id", mcp.Required(), mcp.Description("ID of the check run")),
+		mcp.WithString("name", mcp.Description("New name of the check (default: unchanged)")),
+	}
+	options = append(options, checkRunToolOptions()...)
+
+	return mcp.NewTool(toolName, options...),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				checkRunParams `mapstructure:",squash"`
+				CheckRunID     int64 `mapstructure:"check_run_id"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if params.CheckRunID == 0 {
+				return mcp.NewToolResultError("check_run_id is required"), nil
+			}
+
+			status, err := checkRunState(params.Status, params.Conclusion, "")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			annotations, err := newCheckAnnotations(params.Annotations)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			// The API requires the name on every update, and a title and summary whenever output is sent,
+			// so missing ones are taken from the check run
+			name, title, summary := params.Name, params.Title, params.Summary
+			hasOutput := title != "" || summary != "" || params.Text != "" || len(annotations) > 0
+			if name == "" || (hasOutput && (title == "" || summary == "")) {
+				existing, _, err := client.Checks.GetCheckRun(ctx, owner, repo, params.CheckRunID)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to get check run: %v", err)), nil
+				}
+				if name == "" {
+					name = existing.GetName()
+				}
+				if title == "" {
+					title = existing.GetOutput().GetTitle()
+				}
+				if title == "" {
+					title = name
+				}
+				if summary == "" {
+					summary = existing.GetOutput().GetSummary()
+				}
+			}
+
+			opts := github.UpdateCheckRunOptions{Name: name}
+			if hasOutput {
+				opts.Output = checkRunOutput(title, summary, params.Text, annotations)
+			}
+			if status != "" {
+				opts.Status = github.Ptr(status)
+			}
+			if params.Conclusion != "" {
+				opts.Conclusion = github.Ptr(params.Conclusion)
+				opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+			}
+			if params.DetailsURL != "" {
+				opts.DetailsURL = github.Ptr(params.DetailsURL)
+			}
+
+			checkRun, requests, err := updateCheckRunInBatches(ctx, client, owner, repo, params.CheckRunID, opts, batchAnnotations(annotations))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return checkRunResult(checkRun, len(annotations), requests), nil
+		}
+}
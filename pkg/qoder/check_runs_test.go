@@ -0,0 +1,284 @@
+package qoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestNewCheckAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		params      checkAnnotationParams
+		expectError bool
+		check       func(t *testing.T, annotation *github.CheckRunAnnotation)
+	}{
+		{
+			name:   "defaults end line and level",
+			params: checkAnnotationParams{Path: "main.go", StartLine: 10, Message: "unused variable"},
+			check: func(t *testing.T, annotation *github.CheckRunAnnotation) {
+				if annotation.GetEndLine() != 10 || annotation.GetAnnotationLevel() != "warning" {
+					t.Errorf("expected end line 10 and level warning, got %d and %s", annotation.GetEndLine(), annotation.GetAnnotationLevel())
+				}
+			},
+		},
+		{
+			name:   "keeps columns on a single line",
+			params: checkAnnotationParams{Path: "main.go", StartLine: 3, StartColumn: 5, EndColumn: 9, Message: "typo", Level: "notice"},
+			check: func(t *testing.T, annotation *github.CheckRunAnnotation) {
+				if annotation.GetStartColumn() != 5 || annotation.GetEndColumn() != 9 {
+					t.Errorf("expected columns 5-9, got %d-%d", annotation.GetStartColumn(), annotation.GetEndColumn())
+				}
+			},
+		},
+		{
+			name:   "drops columns on a line range",
+			params: checkAnnotationParams{Path: "main.go", StartLine: 3, EndLine: 6, StartColumn: 5, EndColumn: 9, Message: "typo"},
+			check: func(t *testing.T, annotation *github.CheckRunAnnotation) {
+				if annotation.StartColumn != nil || annotation.EndColumn != nil {
+					t.Errorf("expected no columns, got %d-%d", annotation.GetStartColumn(), annotation.GetEndColumn())
+				}
+			},
+		},
+		{name: "missing path", params: checkAnnotationParams{StartLine: 1, Message: "m"}, expectError: true},
+		{name: "missing message", params: checkAnnotationParams{Path: "a.go", StartLine: 1}, expectError: true},
+		{name: "missing start line", params: checkAnnotationParams{Path: "a.go", Message: "m"}, expectError: true},
+		{name: "end before start", params: checkAnnotationParams{Path: "a.go", StartLine: 5, EndLine: 2, Message: "m"}, expectError: true},
+		{name: "invalid level", params: checkAnnotationParams{Path: "a.go", StartLine: 1, Message: "m", Level: "error"}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations, err := newCheckAnnotations([]checkAnnotationParams{tc.params})
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tc.check(t, annotations[0])
+		})
+	}
+}
+
+func TestBatchAnnotations(t *testing.T) {
+	testCases := []struct {
+		count    int
+		expected []int
+	}{
+		{count: 0, expected: nil},
+		{count: 1, expected: []int{1}},
+		{count: 50, expected: []int{50}},
+		{count: 51, expected: []int{50, 1}},
+		{count: 120, expected: []int{50, 50, 20}},
+	}
+
+	for _, tc := range testCases {
+		annotations := make([]*github.CheckRunAnnotation, tc.count)
+		batches := batchAnnotations(annotations)
+
+		var sizes []int
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch))
+		}
+		if len(sizes) != len(tc.expected) {
+			t.Fatalf("%d annotations: expected batches %v, got %v", tc.count, tc.expected, sizes)
+		}
+		for i := range sizes {
+			if sizes[i] != tc.expected[i] {
+				t.Errorf("%d annotations: expected batches %v, got %v", tc.count, tc.expected, sizes)
+			}
+		}
+	}
+}
+
+func TestAnnotationSummary(t *testing.T) {
+	annotation := func(level string) *github.CheckRunAnnotation {
+		return &github.CheckRunAnnotation{AnnotationLevel: github.Ptr(level)}
+	}
+
+	testCases := []struct {
+		name        string
+		annotations []*github.CheckRunAnnotation
+		expected    string
+	}{
+		{name: "none", expected: "No findings."},
+		{name: "single", annotations: []*github.CheckRunAnnotation{annotation("warning")}, expected: "1 warning"},
+		{
+			name:        "ordered by severity",
+			annotations: []*github.CheckRunAnnotation{annotation("notice"), annotation("failure"), annotation("failure"), annotation("warning")},
+			expected:    "2 failures, 1 warning, 1 notice",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if summary := annotationSummary(tc.annotations); summary != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, summary)
+			}
+		})
+	}
+}
+
+func TestCheckRunState(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      string
+		conclusion  string
+		keepStatus  string
+		expected    string
+		expectError bool
+	}{
+		{name: "default in progress", keepStatus: "in_progress", expected: "in_progress"},
+		{name: "conclusion completes", conclusion: "failure", keepStatus: "in_progress", expected: "completed"},
+		{name: "update keeps status", keepStatus: "", expected: ""},
+		{name: "explicit status", status: "queued", keepStatus: "in_progress", expected: "queued"},
+		{name: "completed without conclusion", status: "completed", expectError: true},
+		{name: "conclusion while running", status: "in_progress", conclusion: "success", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := checkRunState(tc.status, tc.conclusion, tc.keepStatus)
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, status)
+			}
+		})
+	}
+}
+
+func TestUpdateCheckRunInBatches(t *testing.T) {
+	var updates []github.UpdateCheckRunOptions
+	client := github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var update github.UpdateCheckRunOptions
+		if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		updates = append(updates, update)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id": 7, "status": "completed"}`))),
+			Request:    req,
+		}, nil
+	})})
+
+	annotations := make([]*github.CheckRunAnnotation, 120)
+	for i := range annotations {
+		annotations[i] = &github.CheckRunAnnotation{Path: github.Ptr("a.go"), StartLine: github.Ptr(i + 1), AnnotationLevel: github.Ptr("warning")}
+	}
+	opts := github.UpdateCheckRunOptions{
+		Name:       "Qoder Review",
+		Status:     github.Ptr("completed"),
+		Conclusion: github.Ptr("failure"),
+		Output:     checkRunOutput("Qoder Review", "", "", annotations),
+	}
+
+	checkRun, requests, err := updateCheckRunInBatches(context.Background(), client, "owner", "repo", 7, opts, batchAnnotations(annotations))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if checkRun.GetID() != 7 || requests != 3 || len(updates) != 3 {
+		t.Fatalf("expected 3 requests for check run 7, got %d requests and %d updates", requests, len(updates))
+	}
+
+	for i, update := range updates {
+		if update.Name != "Qoder Review" || update.Output.GetTitle() != "Qoder Review" || update.Output.GetSummary() == "" {
+			t.Errorf("update %d: expected name, title and summary on every request, got %+v", i, update)
+		}
+		last := i == len(updates)-1
+		if (update.GetConclusion() != "") != last {
+			t.Errorf("update %d: expected the conclusion only with the last batch, got %q", i, update.GetConclusion())
+		}
+	}
+	if got := updates[0].Output.Annotations[0].GetStartLine(); got != 1 {
+		t.Errorf("expected the first batch to start at line 1, got %d", got)
+	}
+	if got := len(updates[2].Output.Annotations); got != 20 {
+		t.Errorf("expected 20 annotations in the last batch, got %d", got)
+	}
+}
+
+func TestCreateCheckRunInBatches(t *testing.T) {
+	var creates []github.CreateCheckRunOptions
+	var updates []github.UpdateCheckRunOptions
+	client := github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var err error
+		switch req.Method {
+		case http.MethodPost:
+			var create github.CreateCheckRunOptions
+			err = json.NewDecoder(req.Body).Decode(&create)
+			creates = append(creates, create)
+		case http.MethodPatch:
+			var update github.UpdateCheckRunOptions
+			err = json.NewDecoder(req.Body).Decode(&update)
+			updates = append(updates, update)
+		default:
+			t.Fatalf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		if err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id": 7, "status": "in_progress"}`))),
+			Request:    req,
+		}, nil
+	})})
+	getClient := func(ctx context.Context) (*github.Client, error) { return client, nil }
+
+	annotations := make([]interface{}, 70)
+	for i := range annotations {
+		annotations[i] = map[string]interface{}{"path": "a.go", "start_line": float64(i + 1), "message": "finding"}
+	}
+	_, handler := CreateCheckRun(getClient, NewFooterFromConfig("owner", "repo", "", "", FooterConfig{}), "owner", "repo")
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"name":        "Qoder Review",
+		"head_sha":    "abc123",
+		"conclusion":  "success",
+		"annotations": annotations,
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+
+	if len(creates) != 1 || len(updates) != 1 {
+		t.Fatalf("expected 1 create and 1 update, got %d and %d", len(creates), len(updates))
+	}
+	create := creates[0]
+	if create.GetStatus() != "in_progress" || create.GetConclusion() != "" || create.CompletedAt != nil {
+		t.Errorf("expected the check run to be created in progress, got %+v", create)
+	}
+	if got := len(create.Output.Annotations); got != 50 {
+		t.Errorf("expected 50 annotations with the create request, got %d", got)
+	}
+
+	update := updates[0]
+	if update.GetStatus() != "completed" || update.GetConclusion() != "success" || update.CompletedAt == nil {
+		t.Errorf("expected the last update to complete the check run, got %+v", update)
+	}
+	if got := len(update.Output.Annotations); got != 20 {
+		t.Errorf("expected 20 annotations with the last update, got %d", got)
+	}
+}
@@ -0,0 +1,95 @@
+package qoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commentMetaPattern matches the hidden metadata comment appended to our review comments
+var commentMetaPattern = regexp.MustCompile(`<!-- qoder-meta: (\{.*?\}) -->`)
+
+// commentCategoryPattern is the allowed form of a comment category, e.g. "security" or "error-handling"
+var commentCategoryPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// commentMeta is the machine-readable metadata stored in a review comment
+type commentMeta struct {
+	Severity string `json:"severity,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// isEmpty reports whether the metadata has nothing to store
+func (m commentMeta) isEmpty() bool {
+	return m.Severity == "" && m.Category == ""
+}
+
+// newCommentMeta normalizes and validates the severity and category arguments of a comment
+func newCommentMeta(severity, category string) (commentMeta, error) {
+	meta := commentMeta{
+		Severity: strings.ToLower(strings.TrimSpace(severity)),
+		Category: strings.ToLower(strings.TrimSpace(category)),
+	}
+
+	if meta.Severity != "" {
+		if _, ok := severityEmoji[meta.Severity]; !ok {
+			return commentMeta{}, fmt.Errorf("invalid severity %q, must be one of: %s", severity, strings.Join(reviewSeverities, ", "))
+		}
+	}
+	if meta.Category != "" && !commentCategoryPattern.MatchString(meta.Category) {
+		return commentMeta{}, fmt.Errorf("invalid category %q, must be a single lowercase word such as security, performance or style", category)
+	}
+
+	return meta, nil
+}
+
+// renderCommentBadge renders the visible badge for the metadata, e.g. "🟠 **Major** · `security`"
+func renderCommentBadge(meta commentMeta) string {
+	var parts []string
+	if meta.Severity != "" {
+		parts = append(parts, fmt.Sprintf("%s **%s%s**", severityEmoji[meta.Severity], strings.ToUpper(meta.Severity[:1]), meta.Severity[1:]))
+	}
+	if meta.Category != "" {
+		parts = append(parts, "`"+meta.Category+"`")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// withCommentMeta puts the badge above a comment body and the hidden metadata below it
+func withCommentMeta(body string, meta commentMeta) string {
+	if meta.isEmpty() {
+		return body
+	}
+
+	metaJSON, _ := json.Marshal(meta)
+	return renderCommentBadge(meta) + "\n\n" + body + "\n\n<!-- qoder-meta: " + string(metaJSON) + " -->"
+}
+
+// parseCommentMeta reads the hidden metadata of a review comment
+// Returns false if the comment has none
+func parseCommentMeta(body string) (commentMeta, bool) {
+	match := commentMetaPattern.FindStringSubmatch(body)
+	if match == nil {
+		return commentMeta{}, false
+	}
+
+	var meta commentMeta
+	if err := json.Unmarshal([]byte(match[1]), &meta); err != nil {
+		return commentMeta{}, false
+	}
+	return meta, true
+}
+
+// stripCommentMeta removes the badge and hidden metadata added by withCommentMeta, leaving the comment text
+func stripCommentMeta(body string) string {
+	meta, ok := parseCommentMeta(body)
+	if !ok {
+		return body
+	}
+
+	body = commentMetaPattern.ReplaceAllString(body, "")
+	if badge := renderCommentBadge(meta); badge != "" {
+		body = strings.TrimPrefix(body, badge+"\n\n")
+	}
+	return strings.TrimSpace(body)
+}
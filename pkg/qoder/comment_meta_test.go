@@ -0,0 +1,112 @@
+package qoder
+
+import (
+	"testing"
+)
+
+func TestNewCommentMeta(t *testing.T) {
+	testCases := []struct {
+		name        string
+		severity    string
+		category    string
+		expected    commentMeta
+		expectError bool
+	}{
+		{name: "empty", expected: commentMeta{}},
+		{name: "normalizes case", severity: "Major", category: " Security ", expected: commentMeta{Severity: "major", Category: "security"}},
+		{name: "hyphenated category", category: "error-handling", expected: commentMeta{Category: "error-handling"}},
+		{name: "unknown severity", severity: "critical", expectError: true},
+		{name: "category with spaces", category: "code style", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, err := newCommentMeta(tc.severity, tc.category)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %+v", meta)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if meta != tc.expected {
+				t.Errorf("got %+v, expected %+v", meta, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWithCommentMeta(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		meta     commentMeta
+		expected string
+	}{
+		{
+			name:     "no metadata",
+			body:     "Missing error check",
+			meta:     commentMeta{},
+			expected: "Missing error check",
+		},
+		{
+			name:     "severity and category",
+			body:     "Missing error check",
+			meta:     commentMeta{Severity: "major", Category: "correctness"},
+			expected: "🟠 **Major** · `correctness`\n\nMissing error check\n\n<!-- qoder-meta: {\"severity\":\"major\",\"category\":\"correctness\"} -->",
+		},
+		{
+			name:     "category only",
+			body:     "Slow loop",
+			meta:     commentMeta{Category: "performance"},
+			expected: "`performance`\n\nSlow loop\n\n<!-- qoder-meta: {\"category\":\"performance\"} -->",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := withCommentMeta(tc.body, tc.meta)
+			if got != tc.expected {
+				t.Errorf("withCommentMeta() = %q, expected %q", got, tc.expected)
+			}
+
+			// The metadata and original text must be recoverable
+			meta, _ := parseCommentMeta(got)
+			if meta != tc.meta {
+				t.Errorf("parseCommentMeta() = %+v, expected %+v", meta, tc.meta)
+			}
+			if stripped := stripCommentMeta(got); stripped != tc.body {
+				t.Errorf("stripCommentMeta() = %q, expected %q", stripped, tc.body)
+			}
+		})
+	}
+}
+
+func TestParseCommentMeta(t *testing.T) {
+	testCases := []struct {
+		name       string
+		body       string
+		expected   commentMeta
+		expectedOK bool
+	}{
+		{name: "no metadata", body: "plain comment", expectedOK: false},
+		{name: "invalid JSON", body: "<!-- qoder-meta: {oops} -->", expectedOK: false},
+		{
+			name:       "metadata before footer",
+			body:       "text\n\n<!-- qoder-meta: {\"severity\":\"nit\"} -->\n\n---\n🤖 Generated by [Qoder](https://qoder.com/)",
+			expected:   commentMeta{Severity: "nit"},
+			expectedOK: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, ok := parseCommentMeta(tc.body)
+			if ok != tc.expectedOK || meta != tc.expected {
+				t.Errorf("parseCommentMeta() = %+v, %v, expected %+v, %v", meta, ok, tc.expected, tc.expectedOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// pullRequestCommit is the compact representation of a commit in a pull request
+type pullRequestCommit struct {
+	SHA     string    `json:"sha"`
+	Message string    `json:"message"`
+	Author  string    `json:"author,omitempty"`
+	Date    time.Time `json:"date"`
+	Parents []string  `json:"parents,omitempty"`
+}
+
+// newPullRequestCommit converts a go-github commit into its compact representation
+func newPullRequestCommit(c *github.RepositoryCommit) pullRequestCommit {
+	commit := pullRequestCommit{
+		SHA:     c.GetSHA(),
+		Message: strings.TrimSpace(c.GetCommit().GetMessage()),
+		Date:    c.GetCommit().GetAuthor().GetDate().Time,
+	}
+
+	// Prefer the GitHub login, fall back to the git author name
+	if login := c.GetAuthor().GetLogin(); login != "" {
+		commit.Author = login
+	} else {
+		commit.Author = c.GetCommit().GetAuthor().GetName()
+	}
+
+	for _, parent := range c.Parents {
+		commit.Parents = append(commit.Parents, parent.GetSHA())
+	}
+
+	return commit
+}
+
+// ListPullRequestCommits creates a tool to list the commits of a pull request
+func ListPullRequestCommits(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "list_pull_request_commits"
+	description := "List the commits of a pull request in chronological order. The returned SHAs can be passed to get_commit_diff or used as commitId when creating a pending review."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number",
+				mcp.Required(),
+				mcp.Description("Pull request number"),
+			),
+			mcp.WithNumber("page",
+				mcp.Description("Page number for pagination (default: 1)"),
+			),
+			mcp.WithNumber("per_page",
+				mcp.Description("Number of items per page (default: 30, max: 100)"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract required parameters
+			pullNumber, err := getRequiredNumberParam(request, "pull_number")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Extract optional pagination parameters
+			page := getOptionalNumberParam(request, "page")
+			if page == 0 {
+				page = 1
+			}
+
+			perPage := getOptionalNumberParam(request, "per_page")
+			if perPage == 0 {
+				perPage = 30
+			}
+			if perPage > 100 {
+				perPage = 100
+			}
+
+			// Get GitHub client
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			// Fetch commits from GitHub API
+			opts := &github.ListOptions{
+				Page:    page,
+				PerPage: perPage,
+			}
+
+			commits, resp, err := client.PullRequests.ListCommits(ctx, owner, repo, pullNumber, opts)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR commits: %v", err)), nil
+			}
+
+			compactCommits := make([]pullRequestCommit, 0, len(commits))
+			for _, c := range commits {
+				compactCommits = append(compactCommits, newPullRequestCommit(c))
+			}
+
+			// The last page tells the total; otherwise ask the pull request, which counts all its commits
+			totalCount, ok := pageTotal(page, perPage, len(commits), resp)
+			if !ok {
+				totalCount = len(commits)
+				if pr, _, err := client.PullRequests.Get(ctx, owner, repo, pullNumber); err == nil {
+					totalCount = pr.GetCommits()
+				}
+			}
+
+			// Create response structure with pagination info
+			result := struct {
+				Commits    []pullRequestCommit `json:"commits"`
+				Page       int                 `json:"page"`
+				PerPage    int                 `json:"per_page"`
+				HasNext    bool                `json:"has_next"`
+				TotalCount int                 `json:"total_count"`
+			}{
+				Commits:    compactCommits,
+				Page:       page,
+				PerPage:    perPage,
+				HasNext:    resp.NextPage > 0,
+				TotalCount: totalCount,
+			}
+
+			// Marshal to JSON and return
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal commits: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
+
+// GetCommitDiff creates a tool to get the diff of a single commit with enhanced line numbers and compression
+func GetCommitDiff(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "get_commit_diff"
+	description := "Get the diff introduced by a single commit, with line numbers showing the file state after the commit. New lines and context lines show their line numbers, deleted lines don't. Automatically applies compression strategies to reduce diff size when needed."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithString("sha",
+				mcp.Required(),
+				mcp.Description("The SHA of the commit, e.g. from list_pull_request_commits"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract parameters
+			sha, err := getRequiredStringParam(request, "sha")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get GitHub client
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			// Get raw diff from GitHub API
+			rawDiff, _, err := client.Repositories.GetCommitRaw(
+				ctx,
+				owner,
+				repo,
+				sha,
+				github.RawOptions{Type: github.Diff},
+			)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get commit diff: %v", err)), nil
+			}
+
+			// Add line numbers and compress the diff
+			result, err := enhanceAndCompressDiff(rawDiff)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcp.NewToolResultText(result), nil
+		}
+}
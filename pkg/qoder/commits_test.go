@@ -0,0 +1,65 @@
+package qoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestNewPullRequestCommit(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		commit         *github.RepositoryCommit
+		expectedAuthor string
+	}{
+		{
+			name: "uses GitHub login when available",
+			commit: &github.RepositoryCommit{
+				SHA:    github.Ptr("abc123"),
+				Author: &github.User{Login: github.Ptr("octocat")},
+				Commit: &github.Commit{
+					Message: github.Ptr("Fix bug\n\nDetails\n"),
+					Author:  &github.CommitAuthor{Name: github.Ptr("Octo Cat"), Date: &github.Timestamp{Time: date}},
+				},
+				Parents: []*github.Commit{{SHA: github.Ptr("parent1")}},
+			},
+			expectedAuthor: "octocat",
+		},
+		{
+			name: "falls back to git author name",
+			commit: &github.RepositoryCommit{
+				SHA: github.Ptr("abc123"),
+				Commit: &github.Commit{
+					Message: github.Ptr("Fix bug\n\nDetails\n"),
+					Author:  &github.CommitAuthor{Name: github.Ptr("Octo Cat"), Date: &github.Timestamp{Time: date}},
+				},
+				Parents: []*github.Commit{{SHA: github.Ptr("parent1")}},
+			},
+			expectedAuthor: "Octo Cat",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newPullRequestCommit(tc.commit)
+			if result.SHA != "abc123" {
+				t.Errorf("SHA = %q; want %q", result.SHA, "abc123")
+			}
+			if result.Message != "Fix bug\n\nDetails" {
+				t.Errorf("Message = %q; want trimmed message", result.Message)
+			}
+			if result.Author != tc.expectedAuthor {
+				t.Errorf("Author = %q; want %q", result.Author, tc.expectedAuthor)
+			}
+			if !result.Date.Equal(date) {
+				t.Errorf("Date = %v; want %v", result.Date, date)
+			}
+			if len(result.Parents) != 1 || result.Parents[0] != "parent1" {
+				t.Errorf("Parents = %v; want [parent1]", result.Parents)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxCompareFiles is the most changed files GitHub lists for a comparison, however it is paginated
+const maxCompareFiles = 300
+
+// changedFile is the compact metadata of a file changed between two refs
+type changedFile struct {
+	Filename         string `json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	PreviousFilename string `json:"previous_filename,omitempty"`
+}
+
+// newChangedFile converts a go-github commit file into its compact metadata, dropping the patch
+func newChangedFile(f *github.CommitFile) changedFile {
+	return changedFile{
+		Filename:         f.GetFilename(),
+		Status:           f.GetStatus(),
+		Additions:        f.GetAdditions(),
+		Deletions:        f.GetDeletions(),
+		Changes:          f.GetChanges(),
+		PreviousFilename: f.GetPreviousFilename(),
+	}
+}
+
+// CompareRefs creates a tool to compare two refs with enhanced line numbers and compression
+func CompareRefs(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "compare_refs"
+	description := "Compare two refs (branches, tags or commit SHAs) as base...head. Returns the comparison status, commits, changed file metadata and the diff with line numbers showing the head file state, in the same format as get_pull_request_diff. All commits are listed; GitHub lists at most 300 changed files, flagged by files_truncated. Automatically applies compression strategies to reduce diff size when needed."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithString("base",
+				mcp.Required(),
+				mcp.Description("The base ref: a branch, tag or commit SHA"),
+			),
+			mcp.WithString("head",
+				mcp.Required(),
+				mcp.Description("The head ref: a branch, tag or commit SHA"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract required parameters
+			base, err := getRequiredStringParam(request, "base")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			head, err := getRequiredStringParam(request, "head")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get GitHub client
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			// Get comparison metadata from GitHub API, paging through the commits
+			// Files and the other metadata only come with the first page
+			var comparison *github.CommitsComparison
+			allCommits, err := fetchAllPages(ctx, func(ctx context.Context, opts github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+				page, resp, err := client.Repositories.CompareCommits(ctx, owner, repo, base, head, &opts)
+				if err != nil {
+					return nil, resp, err
+				}
+				if opts.Page == 1 {
+					comparison = page
+				}
+				return page.Commits, resp, nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to compare refs: %v", err)), nil
+			}
+
+			// Get raw diff from GitHub API
+			rawDiff, _, err := client.Repositories.CompareCommitsRaw(ctx, owner, repo, base, head, github.RawOptions{Type: github.Diff})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get comparison diff: %v", err)), nil
+			}
+
+			// Add line numbers and compress the diff
+			diff, err := enhanceAndCompressDiff(rawDiff)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			commits := make([]pullRequestCommit, 0, len(allCommits))
+			for _, c := range allCommits {
+				commits = append(commits, newPullRequestCommit(c))
+			}
+
+			files := make([]changedFile, 0, len(comparison.Files))
+			for _, f := range comparison.Files {
+				files = append(files, newChangedFile(f))
+			}
+
+			result := struct {
+				Base           string              `json:"base"`
+				Head           string              `json:"head"`
+				MergeBaseSHA   string              `json:"merge_base_sha"`
+				Status         string              `json:"status"`
+				AheadBy        int                 `json:"ahead_by"`
+				BehindBy       int                 `json:"behind_by"`
+				TotalCommits   int                 `json:"total_commits"`
+				Commits        []pullRequestCommit `json:"commits"`
+				Files          []changedFile       `json:"files"`
+				FilesTruncated bool                `json:"files_truncated,omitempty"` // GitHub lists at most 300 files
+				Diff           string              `json:"diff"`
+			}{
+				Base:           base,
+				Head:           head,
+				MergeBaseSHA:   comparison.GetMergeBaseCommit().GetSHA(),
+				Status:         comparison.GetStatus(),
+				AheadBy:        comparison.GetAheadBy(),
+				BehindBy:       comparison.GetBehindBy(),
+				TotalCommits:   comparison.GetTotalCommits(),
+				Commits:        commits,
+				Files:          files,
+				FilesTruncated: len(files) >= maxCompareFiles,
+				Diff:           diff,
+			}
+
+			// Marshal to JSON and return
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal comparison: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
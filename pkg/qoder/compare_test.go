@@ -0,0 +1,32 @@
+package qoder
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestNewChangedFile(t *testing.T) {
+	file := &github.CommitFile{
+		Filename:         github.Ptr("pkg/new.go"),
+		PreviousFilename: github.Ptr("pkg/old.go"),
+		Status:           github.Ptr("renamed"),
+		Additions:        github.Ptr(3),
+		Deletions:        github.Ptr(1),
+		Changes:          github.Ptr(4),
+		Patch:            github.Ptr("@@ -1 +1 @@\n-a\n+b"),
+	}
+
+	result := newChangedFile(file)
+	expected := changedFile{
+		Filename:         "pkg/new.go",
+		Status:           "renamed",
+		Additions:        3,
+		Deletions:        1,
+		Changes:          4,
+		PreviousFilename: "pkg/old.go",
+	}
+	if result != expected {
+		t.Errorf("newChangedFile() = %+v; want %+v", result, expected)
+	}
+}
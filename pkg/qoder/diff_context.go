@@ -0,0 +1,193 @@
+package qoder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultContextLines = 10
+	maxContextLines     = 200
+)
+
+// ExpandDiffContext creates a tool to show the head file content around a line of a PR diff
+func ExpandDiffContext(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "expand_diff_context"
+	description := "Show the lines around a specific line of a pull request file at its head commit. Line numbers match the enhanced diff returned by get_pull_request_diff, and lines added by the pull request are marked with '+'. Use this to look around a change without fetching the whole file."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number",
+				mcp.Required(),
+				mcp.Description("Pull request number"),
+			),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("The relative path to the file in the pull request"),
+			),
+			mcp.WithNumber("line",
+				mcp.Required(),
+				mcp.Description("The line number in the head version of the file, as shown in the enhanced diff"),
+			),
+			mcp.WithNumber("before",
+				mcp.Description(fmt.Sprintf("Number of lines to show before the line (default: %d, max: %d)", defaultContextLines, maxContextLines)),
+			),
+			mcp.WithNumber("after",
+				mcp.Description(fmt.Sprintf("Number of lines to show after the line (default: %d, max: %d)", defaultContextLines, maxContextLines)),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract required parameters
+			pullNumber, err := getRequiredNumberParam(request, "pull_number")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			path, err := getRequiredStringParam(request, "path")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			line, err := getRequiredNumberParam(request, "line")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Extract optional context sizes
+			before := clampContextLines(int(request.GetFloat("before", defaultContextLines)))
+			after := clampContextLines(int(request.GetFloat("after", defaultContextLines)))
+
+			// Get GitHub client
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			content, headSHA, err := getHeadFileContent(ctx, client, owner, repo, pullNumber, path)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			lines := splitFileLines(content)
+			if line < 1 || line > len(lines) {
+				return mcp.NewToolResultError(fmt.Sprintf("line number %d is out of range for file %s (file has %d lines)", line, path, len(lines))), nil
+			}
+
+			// Find the lines added by the pull request so they can be marked
+			file, err := findPullRequestFile(ctx, client, owner, repo, pullNumber, path)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			added := map[int]bool{}
+			if file != nil {
+				added = addedLinesFromPatch(file.GetPatch())
+			}
+
+			start := max(line-before, 1)
+			end := min(line+after, len(lines))
+
+			shortSHA := headSHA
+			if len(shortSHA) > 7 {
+				shortSHA = shortSHA[:7]
+			}
+			header := fmt.Sprintf("File: %s (head %s), lines %d-%d of %d", path, shortSHA, start, end, len(lines))
+			if file == nil {
+				header += " [file not changed in this pull request]"
+			}
+
+			return mcp.NewToolResultText(header + "\n" + buildContextWindow(lines, added, start, end)), nil
+		}
+}
+
+// clampContextLines keeps a requested context size within the allowed range
+func clampContextLines(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > maxContextLines {
+		return maxContextLines
+	}
+	return n
+}
+
+// findPullRequestFile finds a file in the changed files of a pull request
+// Returns nil if the file is not part of the pull request
+func findPullRequestFile(ctx context.Context, client *github.Client, owner, repo string, pullNumber int, path string) (*github.CommitFile, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, pullNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PR files: %w", err)
+		}
+
+		for _, file := range files {
+			if file.GetFilename() == path {
+				return file, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// addedLinesFromPatch returns the new-file line numbers of lines added by a patch
+func addedLinesFromPatch(patch string) map[int]bool {
+	added := map[int]bool{}
+	newLineNum := 0
+	inChunk := false
+
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			_, _, newStart, _, err := parseChunkHeader(line)
+			if err != nil {
+				inChunk = false
+				continue
+			}
+			newLineNum = newStart
+			inChunk = true
+			continue
+		}
+
+		if !inChunk {
+			continue
+		}
+
+		if len(line) == 0 {
+			// Empty line - treat as context
+			newLineNum++
+			continue
+		}
+
+		switch line[0] {
+		case '+':
+			added[newLineNum] = true
+			newLineNum++
+		case ' ':
+			newLineNum++
+		}
+	}
+
+	return added
+}
+
+// buildContextWindow formats lines start..end (1-indexed, inclusive) in the enhanced diff format
+// Added lines are shown as "lineNumber +content", other lines as "lineNumber  content"
+func buildContextWindow(lines []string, added map[int]bool, start, end int) string {
+	var result []string
+	for i := start; i <= end && i <= len(lines); i++ {
+		if added[i] {
+			result = append(result, fmt.Sprintf("%d +%s", i, lines[i-1]))
+		} else {
+			result = append(result, fmt.Sprintf("%d  %s", i, lines[i-1]))
+		}
+	}
+	return strings.Join(result, "\n")
+}
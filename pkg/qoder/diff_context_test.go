@@ -0,0 +1,98 @@
+package qoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddedLinesFromPatch(t *testing.T) {
+	patch := strings.Join([]string{
+		"@@ -1,4 +1,5 @@",
+		" package main",
+		"-import \"fmt\"",
+		"+import (",
+		"+\t\"fmt\"",
+		"+)",
+		" ",
+		"@@ -20,3 +21,4 @@ func main() {",
+		" \tfmt.Println(\"a\")",
+		"+\tfmt.Println(\"b\")",
+		" }",
+	}, "\n")
+
+	added := addedLinesFromPatch(patch)
+
+	expected := []int{2, 3, 4, 22}
+	if len(added) != len(expected) {
+		t.Fatalf("addedLinesFromPatch() returned %d lines, want %d: %v", len(added), len(expected), added)
+	}
+	for _, line := range expected {
+		if !added[line] {
+			t.Errorf("expected line %d to be marked as added", line)
+		}
+	}
+}
+
+func TestAddedLinesFromPatch_Empty(t *testing.T) {
+	if added := addedLinesFromPatch(""); len(added) != 0 {
+		t.Errorf("addedLinesFromPatch(\"\") = %v, want empty", added)
+	}
+}
+
+func TestBuildContextWindow(t *testing.T) {
+	lines := []string{"package main", "", "func main() {", "\tprintln(1)", "}"}
+	added := map[int]bool{4: true}
+
+	testCases := []struct {
+		name     string
+		start    int
+		end      int
+		expected string
+	}{
+		{
+			name:     "window around added line",
+			start:    3,
+			end:      5,
+			expected: "3  func main() {\n4 +\tprintln(1)\n5  }",
+		},
+		{
+			name:     "end beyond file",
+			start:    5,
+			end:      10,
+			expected: "5  }",
+		},
+		{
+			name:     "empty line keeps line number",
+			start:    1,
+			end:      2,
+			expected: "1  package main\n2  ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := buildContextWindow(lines, added, tc.start, tc.end)
+			if result != tc.expected {
+				t.Errorf("buildContextWindow() = %q; want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestClampContextLines(t *testing.T) {
+	testCases := []struct {
+		input    int
+		expected int
+	}{
+		{-5, 0},
+		{0, 0},
+		{15, 15},
+		{maxContextLines + 1, maxContextLines},
+	}
+
+	for _, tc := range testCases {
+		if result := clampContextLines(tc.input); result != tc.expected {
+			t.Errorf("clampContextLines(%d) = %d; want %d", tc.input, result, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package qoder
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// Views select how much of a GitHub object the read tools return
+const (
+	viewMinimal = "minimal" // What an agent needs to act on the object
+	viewReview  = "review"  // Adds the context needed to review, e.g. bodies, hunks and commits
+	viewFull    = "full"    // The GitHub API object as is
+)
+
+// parseView validates a view name; an empty name is the minimal view
+func parseView(view string) (string, error) {
+	switch view {
+	case "":
+		return viewMinimal, nil
+	case viewMinimal, viewReview, viewFull:
+		return view, nil
+	}
+	return "", fmt.Errorf("invalid view '%s', must be one of: %s, %s, %s", view, viewMinimal, viewReview, viewFull)
+}
+
+// ====== Pull Request ======
+
+// branchDTO is the head or base of a pull request
+type branchDTO struct {
+	Ref  string `json:"ref"`
+	SHA  string `json:"sha"`
+	Repo string `json:"repo,omitempty"` // owner/name, set when it differs from the base repository
+}
+
+// pullRequestMinimal is the minimal view of a pull request
+type pullRequestMinimal struct {
+	Number       int       `json:"number"`
+	Title        string    `json:"title"`
+	State        string    `json:"state"`
+	Draft        bool      `json:"draft,omitempty"`
+	Merged       bool      `json:"merged,omitempty"`
+	Author       string    `json:"author"`
+	Head         branchDTO `json:"head"`
+	Base         branchDTO `json:"base"`
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	ChangedFiles int       `json:"changed_files"`
+	URL          string    `json:"url"`
+}
+
+// pullRequestReview is the review view of a pull request
+type pullRequestReview struct {
+	pullRequestMinimal
+	Body               string            `json:"body,omitempty"`
+	Labels             []string          `json:"labels,omitempty"`
+	RequestedReviewers []string          `json:"requested_reviewers,omitempty"`
+	Assignees          []string          `json:"assignees,omitempty"`
+	Mergeable          *bool             `json:"mergeable,omitempty"`
+	MergeableState     string            `json:"mergeable_state,omitempty"`
+	Commits            int               `json:"commits"`
+	Comments           int               `json:"comments"`
+	ReviewComments     int               `json:"review_comments"`
+	CreatedAt          *github.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt          *github.Timestamp `json:"updated_at,omitempty"`
+}
+
+// pullRequestView converts a pull request to the given view
+func pullRequestView(pr *github.PullRequest, view string) any {
+	switch view {
+	case viewFull:
+		return pr
+	case viewReview:
+		result := pullRequestReview{
+			pullRequestMinimal: newPullRequestMinimal(pr),
+			Body:               pr.GetBody(),
+			Mergeable:          pr.Mergeable,
+			MergeableState:     pr.GetMergeableState(),
+			Commits:            pr.GetCommits(),
+			Comments:           pr.GetComments(),
+			ReviewComments:     pr.GetReviewComments(),
+			CreatedAt:          pr.CreatedAt,
+			UpdatedAt:          pr.UpdatedAt,
+		}
+		for _, label := range pr.Labels {
+			result.Labels = append(result.Labels, label.GetName())
+		}
+		for _, user := range pr.RequestedReviewers {
+			result.RequestedReviewers = append(result.RequestedReviewers, user.GetLogin())
+		}
+		for _, team := range pr.RequestedTeams {
+			result.RequestedReviewers = append(result.RequestedReviewers, "team:"+team.GetSlug())
+		}
+		for _, user := range pr.Assignees {
+			result.Assignees = append(result.Assignees, user.GetLogin())
+		}
+		return result
+	default:
+		return newPullRequestMinimal(pr)
+	}
+}
+
+func newPullRequestMinimal(pr *github.PullRequest) pullRequestMinimal {
+	return pullRequestMinimal{
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		State:        pr.GetState(),
+		Draft:        pr.GetDraft(),
+		Merged:       pr.GetMerged(),
+		Author:       pr.GetUser().GetLogin(),
+		Head:         newBranchDTO(pr.GetHead(), pr.GetBase()),
+		Base:         newBranchDTO(pr.GetBase(), nil),
+		Additions:    pr.GetAdditions(),
+		Deletions:    pr.GetDeletions(),
+		ChangedFiles: pr.GetChangedFiles(),
+		URL:          pr.GetHTMLURL(),
+	}
+}
+
+// newBranchDTO converts a branch, naming its repository only when it differs from base (e.g. a fork)
+func newBranchDTO(branch, base *github.PullRequestBranch) branchDTO {
+	result := branchDTO{Ref: branch.GetRef(), SHA: branch.GetSHA()}
+	if base != nil && branch.GetRepo().GetFullName() != base.GetRepo().GetFullName() {
+		result.Repo = branch.GetRepo().GetFullName()
+	}
+	return result
+}
+
+// ====== Review Comments ======
+
+// reviewCommentMinimal is the minimal view of a pull request review comment
+type reviewCommentMinimal struct {
+	ID          int64  `json:"id"`
+	InReplyTo   int64  `json:"in_reply_to,omitempty"`
+	Author      string `json:"author"`
+	Path        string `json:"path"`
+	Line        int    `json:"line,omitempty"`
+	StartLine   int    `json:"start_line,omitempty"`
+	Side        string `json:"side,omitempty"`
+	SubjectType string `json:"subject_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// reviewCommentReview is the review view of a pull request review comment
+type reviewCommentReview struct {
+	reviewCommentMinimal
+	ReviewID          int64             `json:"review_id,omitempty"`
+	CommitID          string            `json:"commit_id,omitempty"`
+	OriginalLine      int               `json:"original_line,omitempty"`
+	OriginalStartLine int               `json:"original_start_line,omitempty"`
+	DiffHunk          string            `json:"diff_hunk,omitempty"`
+	URL               string            `json:"url,omitempty"`
+	CreatedAt         *github.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt         *github.Timestamp `json:"updated_at,omitempty"`
+}
+
+// reviewCommentsView converts review comments to the given view
+func reviewCommentsView(comments []*github.PullRequestComment, view string) any {
+	switch view {
+	case viewFull:
+		return comments
+	case viewReview:
+		result := make([]reviewCommentReview, 0, len(comments))
+		for _, comment := range comments {
+			result = append(result, reviewCommentReview{
+				reviewCommentMinimal: newReviewCommentMinimal(comment),
+				ReviewID:             comment.GetPullRequestReviewID(),
+				CommitID:             comment.GetCommitID(),
+				OriginalLine:         comment.GetOriginalLine(),
+				OriginalStartLine:    comment.GetOriginalStartLine(),
+				DiffHunk:             comment.GetDiffHunk(),
+				URL:                  comment.GetHTMLURL(),
+				CreatedAt:            comment.CreatedAt,
+				UpdatedAt:            comment.UpdatedAt,
+			})
+		}
+		return result
+	default:
+		result := make([]reviewCommentMinimal, 0, len(comments))
+		for _, comment := range comments {
+			result = append(result, newReviewCommentMinimal(comment))
+		}
+		return result
+	}
+}
+
+func newReviewCommentMinimal(comment *github.PullRequestComment) reviewCommentMinimal {
+	return reviewCommentMinimal{
+		ID:          comment.GetID(),
+		InReplyTo:   comment.GetInReplyTo(),
+		Author:      comment.GetUser().GetLogin(),
+		Path:        comment.GetPath(),
+		Line:        comment.GetLine(),
+		StartLine:   comment.GetStartLine(),
+		Side:        comment.GetSide(),
+		SubjectType: comment.GetSubjectType(),
+		Body:        comment.GetBody(),
+	}
+}
+
+// ====== Reviews ======
+
+// reviewMinimal is the minimal view of a pull request review
+type reviewMinimal struct {
+	ID          int64             `json:"id"`
+	Author      string            `json:"author"`
+	State       string            `json:"state"`
+	Body        string            `json:"body,omitempty"`
+	SubmittedAt *github.Timestamp `json:"submitted_at,omitempty"`
+}
+
+// reviewReview is the review view of a pull request review
+type reviewReview struct {
+	reviewMinimal
+	NodeID            string `json:"node_id,omitempty"`
+	CommitID          string `json:"commit_id,omitempty"`
+	AuthorAssociation string `json:"author_association,omitempty"`
+	URL               string `json:"url,omitempty"`
+}
+
+// reviewsView converts reviews to the given view
+func reviewsView(reviews []*github.PullRequestReview, view string) any {
+	switch view {
+	case viewFull:
+		return reviews
+	case viewReview:
+		result := make([]reviewReview, 0, len(reviews))
+		for _, review := range reviews {
+			result = append(result, reviewReview{
+				reviewMinimal:     newReviewMinimal(review),
+				NodeID:            review.GetNodeID(),
+				CommitID:          review.GetCommitID(),
+				AuthorAssociation: review.GetAuthorAssociation(),
+				URL:               review.GetHTMLURL(),
+			})
+		}
+		return result
+	default:
+		result := make([]reviewMinimal, 0, len(reviews))
+		for _, review := range reviews {
+			result = append(result, newReviewMinimal(review))
+		}
+		return result
+	}
+}
+
+func newReviewMinimal(review *github.PullRequestReview) reviewMinimal {
+	return reviewMinimal{
+		ID:          review.GetID(),
+		Author:      review.GetUser().GetLogin(),
+		State:       review.GetState(),
+		Body:        review.GetBody(),
+		SubmittedAt: review.SubmittedAt,
+	}
+}
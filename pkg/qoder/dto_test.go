@@ -0,0 +1,165 @@
+package qoder
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestParseView(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{input: "", expected: viewMinimal},
+		{input: "minimal", expected: viewMinimal},
+		{input: "review", expected: viewReview},
+		{input: "full", expected: viewFull},
+		{input: "verbose", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			view, err := parseView(tc.input)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error for view %q", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if view != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, view)
+			}
+		})
+	}
+}
+
+func TestPullRequestView(t *testing.T) {
+	repo := func(fullName string) *github.Repository {
+		return &github.Repository{FullName: github.Ptr(fullName)}
+	}
+	pr := &github.PullRequest{
+		Number:       github.Ptr(42),
+		Title:        github.Ptr("Add feature"),
+		State:        github.Ptr("open"),
+		Body:         github.Ptr("Long description"),
+		User:         &github.User{Login: github.Ptr("octocat"), AvatarURL: github.Ptr("https://avatars.example.com/u/1")},
+		Head:         &github.PullRequestBranch{Ref: github.Ptr("feature"), SHA: github.Ptr("abc"), Repo: repo("fork/repo")},
+		Base:         &github.PullRequestBranch{Ref: github.Ptr("main"), SHA: github.Ptr("def"), Repo: repo("owner/repo")},
+		Labels:       []*github.Label{{Name: github.Ptr("bug")}},
+		HTMLURL:      github.Ptr("https://github.com/owner/repo/pull/42"),
+		IssueURL:     github.Ptr("https://api.github.com/repos/owner/repo/issues/42"),
+		ChangedFiles: github.Ptr(3),
+	}
+
+	testCases := []struct {
+		view        string
+		contains    []string
+		notContains []string
+	}{
+		{
+			view:        viewMinimal,
+			contains:    []string{`"number":42`, `"author":"octocat"`, `"repo":"fork/repo"`, `"changed_files":3`},
+			notContains: []string{"Long description", "bug", "avatars", "issue_url"},
+		},
+		{
+			view:        viewReview,
+			contains:    []string{`"number":42`, `"body":"Long description"`, `"labels":["bug"]`},
+			notContains: []string{"avatars", "issue_url"},
+		},
+		{
+			view:     viewFull,
+			contains: []string{"avatars", "issue_url"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.view, func(t *testing.T) {
+			data, err := json.Marshal(pullRequestView(pr, tc.view))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, s := range tc.contains {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("expected %s in %s", s, data)
+				}
+			}
+			for _, s := range tc.notContains {
+				if strings.Contains(string(data), s) {
+					t.Errorf("did not expect %s in %s", s, data)
+				}
+			}
+		})
+	}
+}
+
+func TestReviewCommentsView(t *testing.T) {
+	comments := []*github.PullRequestComment{{
+		ID:        github.Ptr(int64(7)),
+		InReplyTo: github.Ptr(int64(5)),
+		User:      &github.User{Login: github.Ptr("octocat")},
+		Path:      github.Ptr("main.go"),
+		Line:      github.Ptr(10),
+		Side:      github.Ptr("RIGHT"),
+		Body:      github.Ptr("Looks off"),
+		DiffHunk:  github.Ptr("@@ -1,3 +1,4 @@"),
+		HTMLURL:   github.Ptr("https://github.com/owner/repo/pull/1#discussion_r7"),
+	}}
+
+	minimal, err := json.Marshal(reviewCommentsView(comments, viewMinimal))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[{"id":7,"in_reply_to":5,"author":"octocat","path":"main.go","line":10,"side":"RIGHT","body":"Looks off"}]`
+	if string(minimal) != expected {
+		t.Errorf("expected %s, got %s", expected, minimal)
+	}
+
+	review, err := json.Marshal(reviewCommentsView(comments, viewReview))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{`"diff_hunk":"@@ -1,3 +1,4 @@"`, `"url":"https://github.com/owner/repo/pull/1#discussion_r7"`, `"id":7`} {
+		if !strings.Contains(string(review), s) {
+			t.Errorf("expected %s in %s", s, review)
+		}
+	}
+
+	if empty, _ := json.Marshal(reviewCommentsView(nil, viewMinimal)); string(empty) != "[]" {
+		t.Errorf("expected an empty list, got %s", empty)
+	}
+}
+
+func TestReviewsView(t *testing.T) {
+	reviews := []*github.PullRequestReview{{
+		ID:                github.Ptr(int64(3)),
+		User:              &github.User{Login: github.Ptr("octocat")},
+		State:             github.Ptr("APPROVED"),
+		CommitID:          github.Ptr("abc"),
+		AuthorAssociation: github.Ptr("MEMBER"),
+	}}
+
+	minimal, err := json.Marshal(reviewsView(reviews, viewMinimal))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[{"id":3,"author":"octocat","state":"APPROVED"}]`
+	if string(minimal) != expected {
+		t.Errorf("expected %s, got %s", expected, minimal)
+	}
+
+	review, err := json.Marshal(reviewsView(reviews, viewReview))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `[{"id":3,"author":"octocat","state":"APPROVED","commit_id":"abc","author_association":"MEMBER"}]`
+	if string(review) != expected {
+		t.Errorf("expected %s, got %s", expected, review)
+	}
+}
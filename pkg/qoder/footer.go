@@ -0,0 +1,186 @@
+package qoder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/qoder/qoder-github-mcp-server/pkg/fixlink"
+)
+
+// defaultFooterTemplate is the footer used when no footer template is configured
+const defaultFooterTemplate = `🤖 Generated by [Qoder](https://qoder.com/){{if .RunURL}} • [View workflow run]({{.RunURL}}){{end}}{{if .FixURL}} • [One-Click Qoder Fix]({{.FixURL}}){{end}}`
+
+// footerMarker separates a comment from its footer so the footer can be recognized and stripped later
+const footerMarker = "<!-- qoder-footer -->"
+
+// FooterData is the data available to the footer template
+type FooterData struct {
+	Owner      string // Repository owner
+	Repo       string // Repository name
+	RunID      string // GitHub Actions run ID, empty outside Actions
+	RunURL     string // GitHub Actions run URL, empty outside Actions
+	PullNumber int    // Pull request number, 0 if the tool has none
+	CommitSHA  string // Commit the comment refers to, empty if unknown
+	ToolName   string // Name of the tool posting the comment
+	FixURL     string // One-click fix link, empty if fix links are disabled or the tool has none
+}
+
+// Footer renders the footer appended to the comments and reviews we post
+type Footer struct {
+	tmpl          *template.Template
+	disabledTools map[string]bool
+	disabledAll   bool
+	owner         string
+	repo          string
+	runID         string
+	serverURL     string
+	fixLinks      *fixlink.Signer
+}
+
+// NewFooter creates a footer from a text/template and a list of tools that post without a footer
+// An empty template uses the default footer; "*" in disabledTools disables the footer for all tools.
+// fixLinks signs one-click fix links and may be nil to disable them.
+func NewFooter(owner, repo, runID, serverURL, templateText string, disabledTools []string, fixLinks *fixlink.Signer) (*Footer, error) {
+	if templateText == "" {
+		templateText = defaultFooterTemplate
+	}
+
+	tmpl, err := template.New("footer").Parse(templateText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid footer template: %w", err)
+	}
+
+	f := &Footer{
+		tmpl:          tmpl,
+		disabledTools: map[string]bool{},
+		owner:         owner,
+		repo:          repo,
+		runID:         runID,
+		serverURL:     serverURL,
+		fixLinks:      fixLinks,
+	}
+	for _, tool := range disabledTools {
+		tool = strings.TrimSpace(tool)
+		if tool == "*" {
+			f.disabledAll = true
+		} else if tool != "" {
+			f.disabledTools[tool] = true
+		}
+	}
+	return f, nil
+}
+
+// FooterConfig configures the footer appended to posted comments
+type FooterConfig struct {
+	Template      string   // text/template of the footer; empty uses the default footer
+	DisabledTools []string // Tools that post without a footer; "*" disables it for all tools
+
+	FixLinkEndpoint string // Endpoint of one-click fix links; empty disables them
+	FixLinkSecret   string // HMAC key signing fix links
+	FixLinkMaxBytes int    // Maximum length of a fix link's context; 0 uses the default
+}
+
+// NewFooterFromConfig creates a footer from its configuration
+// An invalid template falls back to the default footer and an invalid fix link setup disables them.
+func NewFooterFromConfig(owner, repo, runID, serverURL string, cfg FooterConfig) *Footer {
+	var fixLinks *fixlink.Signer
+	if cfg.FixLinkEndpoint != "" {
+		var err error
+		fixLinks, err = fixlink.NewSigner(cfg.FixLinkEndpoint, cfg.FixLinkSecret, cfg.FixLinkMaxBytes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to configure fix links, they are disabled: %v\n", err)
+			fixLinks = nil
+		}
+	}
+
+	footer, err := NewFooter(owner, repo, runID, serverURL, cfg.Template, cfg.DisabledTools, fixLinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the footer template, using the default footer: %v\n", err)
+		footer, _ = NewFooter(owner, repo, runID, serverURL, "", cfg.DisabledTools, fixLinks)
+	}
+	return footer
+}
+
+// RunID returns the ID of the GitHub Actions run, or "" outside Actions
+func (f *Footer) RunID() string {
+	return f.runID
+}
+
+// RunURL returns the URL of the GitHub Actions run, or "" outside Actions
+func (f *Footer) RunURL() string {
+	if f.runID == "" || f.serverURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s/actions/runs/%s", f.serverURL, f.owner, f.repo, f.runID)
+}
+
+// FixLink returns the signed one-click fix link for a comment, or "" if fix links are disabled
+func (f *Footer) FixLink(ctx fixlink.Context) string {
+	if f.fixLinks == nil {
+		return ""
+	}
+
+	link, err := f.fixLinks.Link(ctx)
+	if err != nil {
+		// A missing fix link must not block the comment
+		fmt.Fprintf(os.Stderr, "Failed to build fix link: %v\n", err)
+		return ""
+	}
+	return link
+}
+
+// Enabled reports whether a tool posts with a footer
+func (f *Footer) Enabled(toolName string) bool {
+	return !f.disabledAll && !f.disabledTools[toolName]
+}
+
+// Data returns the footer data for a tool, with the server's repository and run filled in
+func (f *Footer) Data(toolName string, pullNumber int, commitSHA string) FooterData {
+	return FooterData{
+		Owner:      f.owner,
+		Repo:       f.repo,
+		RunID:      f.runID,
+		RunURL:     f.RunURL(),
+		PullNumber: pullNumber,
+		CommitSHA:  commitSHA,
+		ToolName:   toolName,
+	}
+}
+
+// Render renders the footer text, without separator
+// Returns "" if the footer is disabled for the tool or renders empty
+func (f *Footer) Render(data FooterData) string {
+	if !f.Enabled(data.ToolName) {
+		return ""
+	}
+
+	var sb strings.Builder
+	if err := f.tmpl.Execute(&sb, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render footer: %v\n", err)
+		return ""
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Append adds the footer below a comment body
+// An empty body gets the footer alone, without the separator
+func (f *Footer) Append(body string, data FooterData) string {
+	text := f.Render(data)
+	if text == "" {
+		return body
+	}
+	if body == "" {
+		return footerMarker + "\n" + text
+	}
+	return body + "\n\n" + footerMarker + "\n\n---\n" + text
+}
+
+// stripFooter removes a footer added by Footer.Append from a comment body
+func stripFooter(body string) string {
+	if idx := strings.LastIndex(body, footerMarker); idx != -1 {
+		return strings.TrimSpace(body[:idx])
+	}
+	return body
+}
@@ -0,0 +1,117 @@
+package qoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qoder/qoder-github-mcp-server/pkg/fixlink"
+)
+
+func TestFooterAppend(t *testing.T) {
+	testCases := []struct {
+		name          string
+		runID         string
+		serverURL     string
+		template      string
+		disabledTools []string
+		body          string
+		toolName      string
+		expected      string
+	}{
+		{
+			name:     "default footer without run",
+			body:     "Looks good",
+			toolName: "reply_comment",
+			expected: "Looks good\n\n<!-- qoder-footer -->\n\n---\n🤖 Generated by [Qoder](https://qoder.com/)",
+		},
+		{
+			name:      "default footer with run",
+			runID:     "42",
+			serverURL: "https://github.com",
+			body:      "Looks good",
+			toolName:  "reply_comment",
+			expected:  "Looks good\n\n<!-- qoder-footer -->\n\n---\n🤖 Generated by [Qoder](https://qoder.com/) • [View workflow run](https://github.com/octo/repo/actions/runs/42)",
+		},
+		{
+			name:     "custom template",
+			template: "Posted by {{.ToolName}} on #{{.PullNumber}} at {{.CommitSHA}}",
+			body:     "Looks good",
+			toolName: "add_comment_to_pending_review",
+			expected: "Looks good\n\n<!-- qoder-footer -->\n\n---\nPosted by add_comment_to_pending_review on #7 at abc123",
+		},
+		{
+			name:     "empty body",
+			body:     "",
+			toolName: "submit_pending_pull_request_review",
+			expected: "<!-- qoder-footer -->\n🤖 Generated by [Qoder](https://qoder.com/)",
+		},
+		{
+			name:          "disabled for tool",
+			disabledTools: []string{"reply_comment", " update_comment "},
+			body:          "Looks good",
+			toolName:      "update_comment",
+			expected:      "Looks good",
+		},
+		{
+			name:          "disabled for all tools",
+			disabledTools: []string{"*"},
+			body:          "Looks good",
+			toolName:      "reply_comment",
+			expected:      "Looks good",
+		},
+		{
+			name:     "template rendering empty",
+			template: "{{if .RunURL}}run {{.RunURL}}{{end}}",
+			body:     "Looks good",
+			toolName: "reply_comment",
+			expected: "Looks good",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			footer, err := NewFooter("octo", "repo", tc.runID, tc.serverURL, tc.template, tc.disabledTools, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := footer.Append(tc.body, footer.Data(tc.toolName, 7, "abc123"))
+			if got != tc.expected {
+				t.Errorf("Append() = %q, expected %q", got, tc.expected)
+			}
+			if stripped := stripFooter(got); stripped != tc.body {
+				t.Errorf("stripFooter() = %q, expected %q", stripped, tc.body)
+			}
+		})
+	}
+}
+
+func TestNewFooterInvalidTemplate(t *testing.T) {
+	if _, err := NewFooter("octo", "repo", "", "", "{{.Missing", nil, nil); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestFooterFixLink(t *testing.T) {
+	noLinks, _ := NewFooter("octo", "repo", "", "", "", nil, nil)
+	if link := noLinks.FixLink(fixlink.Context{Owner: "octo"}); link != "" {
+		t.Errorf("expected no fix link when disabled, got %q", link)
+	}
+
+	signer, err := fixlink.NewSigner("https://fix.example.com/fix", "secret", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	footer, _ := NewFooter("octo", "repo", "", "", "", nil, signer)
+
+	data := footer.Data("add_comment_to_pending_review", 7, "abc123")
+	data.FixURL = footer.FixLink(fixlink.Context{Owner: "octo", Repo: "repo", PullNumber: 7, Body: "text"})
+	if !strings.HasPrefix(data.FixURL, "https://fix.example.com/fix?context=v1.") {
+		t.Fatalf("unexpected fix link %q", data.FixURL)
+	}
+
+	expected := "🤖 Generated by [Qoder](https://qoder.com/) • [One-Click Qoder Fix](" + data.FixURL + ")"
+	if got := footer.Render(data); got != expected {
+		t.Errorf("Render() = %q, expected %q", got, expected)
+	}
+}
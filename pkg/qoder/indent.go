@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v73/github"
 )
@@ -72,148 +73,256 @@ func getIndentation(line string) string {
 	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
 }
 
-// ====== Main Adjustment Function ======
+// indentationWidth returns the number of columns an indentation string takes
+// Tabs advance to the next multiple of tabWidth
+func indentationWidth(indentation string, tabWidth int) int {
+	if tabWidth <= 0 {
+		tabWidth = defaultIndentWidth
+	}
+	cols := 0
+	for _, c := range indentation {
+		if c == '\t' {
+			cols += tabWidth - cols%tabWidth
+		} else {
+			cols++
+		}
+	}
+	return cols
+}
 
-// adjustSuggestionIndentation adjusts the indentation of a suggestion block in a comment body
-func adjustSuggestionIndentation(ctx context.Context, client *github.Client, owner, repo string, pullNumber int, path string, line int, body string) (string, error) {
-	suggestion, err := extractSuggestionBlock(body)
-	if err != nil {
-		// If no suggestion block is found, return the original body
-		return body, nil
+// renderIndentation renders a number of columns of indentation in the given style
+// With tabs, columns that do not fill a whole level are kept as spaces for alignment
+func renderIndentation(cols int, style indentStyle) string {
+	if cols <= 0 {
+		return ""
 	}
-	suggestion = strings.TrimSpace(suggestion)
-	if suggestion == "" {
-		return body, nil
+	if !style.UseTabs || style.Width <= 0 {
+		return strings.Repeat(" ", cols)
 	}
+	return strings.Repeat("\t", cols/style.Width) + strings.Repeat(" ", cols%style.Width)
+}
 
-	// Get the indentation from the original code
-	correctIndentation, err := getOriginalCodeIndentation(ctx, client, owner, repo, pullNumber, path, line)
-	if err != nil {
-		return "", fmt.Errorf("failed to get original code indentation: %w", err)
+// detectIndentUnit detects the indentation step of a code block from its line widths
+// Blank lines are marked with a negative width; returns fallback if no step is found
+func detectIndentUnit(widths []int, fallback int) int {
+	deltaCounts := map[int]int{}
+	prev := -1
+	for _, width := range widths {
+		if width < 0 {
+			continue
+		}
+		if prev >= 0 && width > prev {
+			deltaCounts[width-prev]++
+		}
+		prev = width
 	}
 
-	// Use the new independent methods for indentation adjustment
-	baseIndentation := detectBaseIndentation(suggestion)
+	// Ties go to the fallback, so alignment columns do not outvote real nesting
+	unit, bestCount := fallback, deltaCounts[fallback]
+	for _, candidate := range []int{2, 4, 3, 8} {
+		if deltaCounts[candidate] > bestCount {
+			unit, bestCount = candidate, deltaCounts[candidate]
+		}
+	}
+	return unit
+}
 
-	// Step 1: Remove base indentation (if any)
-	var unindentedSuggestion string
-	if baseIndentation != "" {
-		unindentedSuggestion = removeBaseIndentation(suggestion, baseIndentation)
-	} else {
-		// No base indentation to remove, use suggestion as-is
-		unindentedSuggestion = suggestion
+// scaleIndent converts relative indentation from one indentation step to another
+// Whole levels are scaled, leftover alignment columns are kept as-is
+func scaleIndent(cols, fromUnit, toUnit int) int {
+	if fromUnit <= 0 || toUnit <= 0 || fromUnit == toUnit {
+		return cols
+	}
+	sign := 1
+	if cols < 0 {
+		sign, cols = -1, -cols
 	}
+	return sign * (cols/fromUnit*toUnit + cols%fromUnit)
+}
 
-	// Step 2: Apply target indentation
-	adjustedSuggestion := applyBaseIndentation(unindentedSuggestion, correctIndentation)
+// ====== Main Adjustment Function ======
 
-	// Re-insert the adjusted suggestion into the original body
-	// We need to find the full original suggestion block to replace it correctly
-	originalSuggestionBlock, err := getFullSuggestionBlock(body)
+// adjustSuggestionIndentation adjusts the indentation of every suggestion block in a comment body
+func adjustSuggestionIndentation(ctx context.Context, file *headFile, line int, body string) (string, error) {
+	// Only look up the original code if there is something to adjust
+	hasSuggestion := false
+	for _, block := range findSuggestionBlocks(body) {
+		if strings.TrimSpace(block.Content) != "" {
+			hasSuggestion = true
+			break
+		}
+	}
+	if !hasSuggestion {
+		return body, nil
+	}
+
+	// Get the indentation and indentation style from the original code
+	correctIndentation, style, err := getOriginalCodeIndentation(ctx, file, line)
 	if err != nil {
-		return body, nil // Should not happen if extractSuggestionBlock succeeded
+		return "", fmt.Errorf("failed to get original code indentation: %w", err)
 	}
-	finalSuggestionBlock := "```suggestion\n" + adjustedSuggestion + "\n```"
 
-	return strings.Replace(body, originalSuggestionBlock, finalSuggestionBlock, 1), nil
+	return reindentSuggestionBlocks(body, correctIndentation, style), nil
 }
 
-// ====== Helper Functions ======
+// reindentSuggestionBlocks re-indents each suggestion block in a comment body independently
+// All blocks of a comment replace the same lines, so they share the same target indentation
+func reindentSuggestionBlocks(body string, targetIndentation string, style indentStyle) string {
+	blocks := findSuggestionBlocks(body)
+
+	// Replace from the last block to the first so earlier offsets stay valid
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		suggestion := trimBlankLines(block.Content)
+		if suggestion == "" {
+			// An empty suggestion deletes the lines, nothing to adjust
+			continue
+		}
 
-// getOriginalCodeIndentation gets the indentation of the original code at a specific line
-func getOriginalCodeIndentation(ctx context.Context, client *github.Client, owner, repo string, pullNumber int, path string, line int) (string, error) {
-	if line < 1 {
-		return "", fmt.Errorf("invalid line number %d, must be >= 1", line)
+		adjustedSuggestion := reindentSuggestion(suggestion, targetIndentation, style)
+		body = body[:block.Start] + block.render(adjustedSuggestion) + body[block.End:]
 	}
 
-	pr, _, err := client.PullRequests.Get(ctx, owner, repo, pullNumber)
-	if err != nil {
-		return "", fmt.Errorf("failed to get pull request: %w", err)
+	return body
+}
+
+// reindentSuggestion moves a suggestion to the target base indentation in the file's indentation style
+// The first non-empty line is the base; nested lines keep their relative depth, converted from the
+// suggestion's own indentation step to the file's, so space-indented suggestions fit tab-indented files
+func reindentSuggestion(suggestion string, targetIndentation string, style indentStyle) string {
+	if style.Width <= 0 {
+		style.Width = defaultIndentWidth
 	}
 
-	if pr.GetHead() == nil || pr.GetHead().GetSHA() == "" {
-		return "", fmt.Errorf("pull request head commit SHA is empty")
+	lines := strings.Split(suggestion, "\n")
+	widths := make([]int, len(lines))
+	baseWidth := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			widths[i] = -1
+			continue
+		}
+		widths[i] = indentationWidth(getIndentation(line), style.Width)
+		if baseWidth < 0 {
+			baseWidth = widths[i]
+		}
 	}
 
-	commitSHA := pr.GetHead().GetSHA()
+	unit := detectIndentUnit(widths, style.Width)
+	targetWidth := indentationWidth(targetIndentation, style.Width)
 
-	fileContent, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: commitSHA})
-	if err != nil {
-		return "", fmt.Errorf("failed to get file content: %w", err)
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		if widths[i] < 0 {
+			// Keep empty lines empty
+			continue
+		}
+
+		relative := scaleIndent(widths[i]-baseWidth, unit, style.Width)
+		var indentation string
+		if relative >= 0 {
+			indentation = targetIndentation + renderIndentation(relative, style)
+		} else {
+			// Lines shallower than the first line are placed relative to the target's width
+			indentation = renderIndentation(targetWidth+relative, style)
+		}
+		result[i] = indentation + strings.TrimLeft(line, " \t")
 	}
 
-	if fileContent == nil {
-		return "", fmt.Errorf("file content is nil")
+	return strings.Join(result, "\n")
+}
+
+// trimBlankLines removes leading blank lines and trailing whitespace from a code block
+// Unlike strings.TrimSpace it keeps the indentation of the first line
+func trimBlankLines(codeBlock string) string {
+	lines := strings.Split(strings.TrimRight(codeBlock, " \t\r\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
 	}
+	return strings.Join(lines, "\n")
+}
 
-	content, err := fileContent.GetContent()
+// ====== Helper Functions ======
+
+// getOriginalCodeIndentation gets the indentation of the original code at a specific line,
+// along with the indentation style of the file
+func getOriginalCodeIndentation(ctx context.Context, file *headFile, line int) (string, indentStyle, error) {
+	if line < 1 {
+		return "", indentStyle{}, fmt.Errorf("invalid line number %d, must be >= 1", line)
+	}
+
+	content, commitSHA, err := file.get(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode file content: %w", err)
+		return "", indentStyle{}, err
 	}
 
 	lines := strings.Split(content, "\n")
 	if line > len(lines) {
-		return "", fmt.Errorf("line number %d is out of range for file %s (file has %d lines)", line, path, len(lines))
+		return "", indentStyle{}, fmt.Errorf("line number %d is out of range for file %s (file has %d lines)", line, file.path, len(lines))
 	}
 
+	style := resolveIndentStyle(ctx, file.client, file.owner, file.repo, commitSHA, file.path, content)
+
 	// Lines are 1-indexed, arrays are 0-indexed
 	targetLine := lines[line-1]
-	return getIndentation(targetLine), nil
+	return getIndentation(targetLine), style, nil
 }
 
-// extractSuggestionBlock extracts the suggestion block from a comment body
-func extractSuggestionBlock(body string) (string, error) {
-	const suggestionStart = "```suggestion"
-	const suggestionEnd = "```"
+// headFile is a file at the head commit of a pull request, fetched on first use so the steps handling
+// one comment share a single fetch
+type headFile struct {
+	client     *github.Client
+	owner      string
+	repo       string
+	pullNumber int
+	path       string
+
+	once      sync.Once
+	content   string
+	commitSHA string
+	err       error
+}
 
-	startIndex := strings.Index(body, suggestionStart)
-	if startIndex == -1 {
-		return "", fmt.Errorf("no suggestion block found")
-	}
+// newHeadFile creates a lazily fetched head file
+func newHeadFile(client *github.Client, owner, repo string, pullNumber int, path string) *headFile {
+	return &headFile{client: client, owner: owner, repo: repo, pullNumber: pullNumber, path: path}
+}
 
-	startIndex += len(suggestionStart)
-	// Handle optional newline after suggestion start
-	if startIndex < len(body) && body[startIndex] == '\n' {
-		startIndex++
-	}
+// get returns the decoded file content and the head commit SHA
+func (f *headFile) get(ctx context.Context) (string, string, error) {
+	f.once.Do(func() {
+		f.content, f.commitSHA, f.err = getHeadFileContent(ctx, f.client, f.owner, f.repo, f.pullNumber, f.path)
+	})
+	return f.content, f.commitSHA, f.err
+}
 
-	// Look for the end marker starting from after the start marker
-	endIndex := strings.Index(body[startIndex:], suggestionEnd)
-	if endIndex == -1 {
-		return "", fmt.Errorf("suggestion block not properly terminated")
+// getHeadFileContent gets the content of a file at the head commit of a pull request
+// Returns the decoded file content and the head commit SHA
+func getHeadFileContent(ctx context.Context, client *github.Client, owner, repo string, pullNumber int, path string) (string, string, error) {
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, pullNumber)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get pull request: %w", err)
 	}
 
-	// Make sure we don't go out of bounds
-	actualEndIndex := startIndex + endIndex
-	if actualEndIndex > len(body) {
-		return "", fmt.Errorf("suggestion block end index out of bounds")
+	if pr.GetHead() == nil || pr.GetHead().GetSHA() == "" {
+		return "", "", fmt.Errorf("pull request head commit SHA is empty")
 	}
 
-	return body[startIndex:actualEndIndex], nil
-}
-
-// getFullSuggestionBlock extracts the full suggestion block from a comment body
-func getFullSuggestionBlock(body string) (string, error) {
-	const suggestionStart = "```suggestion"
-	const suggestionEnd = "```"
+	commitSHA := pr.GetHead().GetSHA()
 
-	startIndex := strings.Index(body, suggestionStart)
-	if startIndex == -1 {
-		return "", fmt.Errorf("no suggestion block found")
+	fileContent, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: commitSHA})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get file content: %w", err)
 	}
 
-	// Look for the end marker starting from after the start marker
-	searchStart := startIndex + len(suggestionStart)
-	endIndex := strings.Index(body[searchStart:], suggestionEnd)
-	if endIndex == -1 {
-		return "", fmt.Errorf("suggestion block not properly terminated")
+	if fileContent == nil {
+		return "", "", fmt.Errorf("file content is nil")
 	}
 
-	// Calculate the actual end position including the end marker
-	actualEndIndex := searchStart + endIndex + len(suggestionEnd)
-	if actualEndIndex > len(body) {
-		return "", fmt.Errorf("suggestion block end index out of bounds")
+	content, err := fileContent.GetContent()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode file content: %w", err)
 	}
 
-	return body[startIndex:actualEndIndex], nil
+	return content, commitSHA, nil
 }
@@ -0,0 +1,347 @@
+package qoder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// defaultIndentWidth is used when neither .editorconfig nor the file content tells the indentation width
+const defaultIndentWidth = 4
+
+// maxEditorConfigCacheEntries bounds the .editorconfig lookups remembered for the session
+const maxEditorConfigCacheEntries = 1024
+
+// indentStyle describes how a file indents its code
+type indentStyle struct {
+	UseTabs bool // Indent with tabs instead of spaces
+	Width   int  // Columns per indentation level, also used as the tab width
+}
+
+// ====== Style Detection ======
+
+// defaultIndentStyle returns the conventional indentation style for a file when nothing else is known
+func defaultIndentStyle(filePath string) indentStyle {
+	base := path.Base(filePath)
+	ext := strings.ToLower(path.Ext(filePath))
+
+	// gofmt and make both require tabs
+	if ext == ".go" || ext == ".mk" || base == "Makefile" || base == "makefile" || base == "GNUmakefile" {
+		return indentStyle{UseTabs: true, Width: defaultIndentWidth}
+	}
+	return indentStyle{UseTabs: false, Width: defaultIndentWidth}
+}
+
+// detectIndentStyle infers the indentation style of a file from its content
+// Returns false if the file has no indented lines to learn from
+func detectIndentStyle(content string) (indentStyle, bool) {
+	tabLines, spaceLines := 0, 0
+	deltaCounts := map[int]int{}
+	prevSpaces := 0
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '\t':
+			tabLines++
+			continue
+		case ' ':
+			indent := getIndentation(line)
+			// Skip block comment continuations like " * text", they are aligned rather than indented
+			if strings.HasPrefix(line[len(indent):], "*") {
+				continue
+			}
+			if !strings.Contains(indent, "\t") {
+				spaceLines++
+				if delta := len(indent) - prevSpaces; delta > 0 {
+					deltaCounts[delta]++
+				}
+				prevSpaces = len(indent)
+				continue
+			}
+		}
+		prevSpaces = 0
+	}
+
+	if tabLines == 0 && spaceLines == 0 {
+		return indentStyle{}, false
+	}
+	if tabLines > spaceLines {
+		return indentStyle{UseTabs: true, Width: defaultIndentWidth}, true
+	}
+
+	// The most common indentation increase between consecutive lines is the indentation width
+	width, bestCount := defaultIndentWidth, 0
+	for _, candidate := range []int{2, 4, 3, 8} {
+		if deltaCounts[candidate] > bestCount {
+			width, bestCount = candidate, deltaCounts[candidate]
+		}
+	}
+	return indentStyle{UseTabs: false, Width: width}, true
+}
+
+// resolveIndentStyle determines the indentation style of a file at a commit
+// The file content decides when it has indented lines; otherwise .editorconfig settings apply over the
+// conventions for the file type
+func resolveIndentStyle(ctx context.Context, client *github.Client, owner, repo, ref, filePath, content string) indentStyle {
+	if detected, ok := detectIndentStyle(content); ok {
+		return detected
+	}
+
+	props := loadEditorConfig(ctx, client, owner, repo, ref, filePath)
+	return applyEditorConfig(defaultIndentStyle(filePath), props)
+}
+
+// ====== EditorConfig ======
+
+// loadEditorConfig collects the .editorconfig properties that apply to a file at a commit
+// Missing or unreadable .editorconfig files are ignored
+func loadEditorConfig(ctx context.Context, client *github.Client, owner, repo, ref, filePath string) map[string]string {
+	// Walk from the file's directory up to the repository root, nearest file first
+	type editorConfigFile struct {
+		dir     string
+		content string
+	}
+	var files []editorConfigFile
+
+	dir := path.Dir(filePath)
+	for {
+		if dir == "." || dir == "/" {
+			dir = ""
+		}
+
+		if content, ok := getEditorConfig(ctx, client, owner, repo, ref, dir); ok {
+			files = append(files, editorConfigFile{dir: dir, content: content})
+			if isEditorConfigRoot(content) {
+				break
+			}
+		}
+
+		if dir == "" {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+
+	// Apply from the farthest to the nearest file so nearer settings win
+	props := map[string]string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		relPath := strings.TrimPrefix(strings.TrimPrefix(filePath, files[i].dir), "/")
+		for key, value := range parseEditorConfig(files[i].content, relPath) {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// editorConfigCache remembers the .editorconfig file of each directory at each commit, including missing
+// ones, so comments on the same files do not look them up again
+var editorConfigCache = struct {
+	sync.Mutex
+	files map[string]*string // nil for a directory without .editorconfig
+}{files: map[string]*string{}}
+
+// getEditorConfig gets the .editorconfig file of a directory at a commit
+// Returns false if there is none or it cannot be read; only definite answers are remembered
+func getEditorConfig(ctx context.Context, client *github.Client, owner, repo, ref, dir string) (string, bool) {
+	key := owner + "/" + repo + "@" + ref + ":" + dir
+
+	editorConfigCache.Lock()
+	cached, ok := editorConfigCache.files[key]
+	editorConfigCache.Unlock()
+	if ok {
+		if cached == nil {
+			return "", false
+		}
+		return *cached, true
+	}
+
+	var content *string
+	fileContent, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path.Join(dir, ".editorconfig"), &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if !errors.As(err, &errResp) || errResp.Response == nil || errResp.Response.StatusCode != http.StatusNotFound {
+			return "", false
+		}
+	} else if fileContent != nil {
+		decoded, err := fileContent.GetContent()
+		if err != nil {
+			return "", false
+		}
+		content = &decoded
+	}
+
+	editorConfigCache.Lock()
+	if len(editorConfigCache.files) >= maxEditorConfigCacheEntries {
+		editorConfigCache.files = map[string]*string{}
+	}
+	editorConfigCache.files[key] = content
+	editorConfigCache.Unlock()
+
+	if content == nil {
+		return "", false
+	}
+	return *content, true
+}
+
+// isEditorConfigRoot reports whether an .editorconfig file declares root = true in its preamble
+func isEditorConfigRoot(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			return false
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "root") {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+	return false
+}
+
+// parseEditorConfig returns the properties of all sections in an .editorconfig file matching relPath
+// relPath is relative to the directory of the .editorconfig file; later sections override earlier ones
+func parseEditorConfig(content, relPath string) map[string]string {
+	props := map[string]string{}
+	matching := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			matching = editorConfigGlobMatch(line[1:len(line)-1], relPath)
+			continue
+		}
+
+		if !matching {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		props[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	return props
+}
+
+// applyEditorConfig overrides an indentation style with indent_style, indent_size and tab_width properties
+func applyEditorConfig(style indentStyle, props map[string]string) indentStyle {
+	switch props["indent_style"] {
+	case "tab":
+		style.UseTabs = true
+	case "space":
+		style.UseTabs = false
+	}
+
+	if size, err := strconv.Atoi(props["indent_size"]); err == nil && size > 0 {
+		style.Width = size
+	} else if props["indent_size"] == "tab" || style.UseTabs {
+		if width, err := strconv.Atoi(props["tab_width"]); err == nil && width > 0 {
+			style.Width = width
+		}
+	}
+
+	return style
+}
+
+// editorConfigGlobMatch matches a path against an EditorConfig section glob
+// Globs without a slash match the file name in any directory
+func editorConfigGlobMatch(pattern, relPath string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	} else {
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+
+	re, err := regexp.Compile("^" + editorConfigGlobToRegexp(pattern) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(relPath)
+}
+
+// editorConfigGlobToRegexp converts an EditorConfig glob into a regular expression
+func editorConfigGlobToRegexp(pattern string) string {
+	var sb strings.Builder
+	braceDepth := 0
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case '{':
+			// Numeric ranges like {1..3} are matched as any integer
+			if end := strings.IndexByte(pattern[i:], '}'); end != -1 && strings.Contains(pattern[i:i+end], "..") && !strings.Contains(pattern[i:i+end], ",") {
+				sb.WriteString(`[+-]?\d+`)
+				i += end
+				continue
+			}
+			braceDepth++
+			sb.WriteString("(?:")
+		case '}':
+			if braceDepth > 0 {
+				braceDepth--
+				sb.WriteString(")")
+			} else {
+				sb.WriteString(`\}`)
+			}
+		case ',':
+			if braceDepth > 0 {
+				sb.WriteString("|")
+			} else {
+				sb.WriteString(",")
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
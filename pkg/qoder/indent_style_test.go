@@ -0,0 +1,200 @@
+package qoder
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestDetectIndentStyle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected indentStyle
+		ok       bool
+	}{
+		{
+			name:     "Go file with tabs",
+			content:  "package main\n\nfunc main() {\n\tif true {\n\t\tprintln()\n\t}\n}\n",
+			expected: indentStyle{UseTabs: true, Width: defaultIndentWidth},
+			ok:       true,
+		},
+		{
+			name:     "Two-space YAML",
+			content:  "a:\n  b:\n    c: 1\n  d: 2\n",
+			expected: indentStyle{UseTabs: false, Width: 2},
+			ok:       true,
+		},
+		{
+			name:     "Four-space Java with block comments",
+			content:  "/**\n * Doc\n */\nclass A {\n    void f() {\n        g();\n    }\n}\n",
+			expected: indentStyle{UseTabs: false, Width: 4},
+			ok:       true,
+		},
+		{
+			name:    "No indentation",
+			content: "a\nb\nc\n",
+			ok:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, ok := detectIndentStyle(tc.content)
+			if ok != tc.ok {
+				t.Fatalf("detectIndentStyle() ok = %v; want %v", ok, tc.ok)
+			}
+			if ok && result != tc.expected {
+				t.Errorf("detectIndentStyle() = %+v; want %+v", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultIndentStyle(t *testing.T) {
+	testCases := []struct {
+		path    string
+		useTabs bool
+	}{
+		{"main.go", true},
+		{"build/Makefile", true},
+		{"rules.mk", true},
+		{"app.py", false},
+		{"index.ts", false},
+	}
+
+	for _, tc := range testCases {
+		if result := defaultIndentStyle(tc.path); result.UseTabs != tc.useTabs {
+			t.Errorf("defaultIndentStyle(%s).UseTabs = %v; want %v", tc.path, result.UseTabs, tc.useTabs)
+		}
+	}
+}
+
+func TestEditorConfigGlobMatch(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"*", "main.go", true},
+		{"*", "pkg/main.go", true},
+		{"*.go", "pkg/qoder/tools.go", true},
+		{"*.go", "pkg/qoder/tools.py", false},
+		{"*.{js,ts}", "src/app.ts", true},
+		{"*.{js,ts}", "src/app.go", false},
+		{"Makefile", "Makefile", true},
+		{"Makefile", "sub/Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		{"lib/**.js", "lib/a/b/c.js", true},
+		{"lib/*.js", "lib/a/c.js", false},
+		{"src/**/*.py", "src/a.py", true},
+		{"[Mm]akefile", "makefile", true},
+		{"file{1..3}.txt", "file2.txt", true},
+	}
+
+	for _, tc := range testCases {
+		if result := editorConfigGlobMatch(tc.pattern, tc.path); result != tc.expected {
+			t.Errorf("editorConfigGlobMatch(%q, %q) = %v; want %v", tc.pattern, tc.path, result, tc.expected)
+		}
+	}
+}
+
+func TestParseEditorConfig(t *testing.T) {
+	content := strings.Join([]string{
+		"root = true",
+		"",
+		"[*]",
+		"indent_style = space",
+		"indent_size = 4",
+		"",
+		"# Go uses tabs",
+		"[*.go]",
+		"indent_style = tab",
+		"",
+		"[Makefile]",
+		"indent_style = tab",
+		"tab_width = 8",
+	}, "\n")
+
+	testCases := []struct {
+		path     string
+		base     indentStyle
+		expected indentStyle
+	}{
+		{"main.go", indentStyle{UseTabs: false, Width: 2}, indentStyle{UseTabs: true, Width: 4}},
+		{"app/main.py", indentStyle{UseTabs: true, Width: 4}, indentStyle{UseTabs: false, Width: 4}},
+		{"Makefile", indentStyle{UseTabs: false, Width: 4}, indentStyle{UseTabs: true, Width: 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			props := parseEditorConfig(content, tc.path)
+			result := applyEditorConfig(tc.base, props)
+			if result != tc.expected {
+				t.Errorf("applyEditorConfig(parseEditorConfig(%s)) = %+v; want %+v", tc.path, result, tc.expected)
+			}
+		})
+	}
+
+	if !isEditorConfigRoot(content) {
+		t.Errorf("isEditorConfigRoot() = false; want true")
+	}
+	if isEditorConfigRoot("[*]\nroot = true\n") {
+		t.Errorf("isEditorConfigRoot() = true for root inside a section; want false")
+	}
+}
+
+func TestApplyEditorConfig_TabWidth(t *testing.T) {
+	props := map[string]string{"indent_style": "tab", "indent_size": "tab", "tab_width": "8"}
+	result := applyEditorConfig(indentStyle{UseTabs: false, Width: 4}, props)
+	expected := indentStyle{UseTabs: true, Width: 8}
+	if result != expected {
+		t.Errorf("applyEditorConfig() = %+v; want %+v", result, expected)
+	}
+}
+
+func TestLoadEditorConfigCached(t *testing.T) {
+	rootConfig := base64.StdEncoding.EncodeToString([]byte("root = true\n\n[*.py]\nindent_size = 2\n"))
+	var requested []string
+	client := github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.Path)
+		status, body := http.StatusNotFound, `{"message": "Not Found"}`
+		if strings.HasSuffix(req.URL.Path, "/contents/.editorconfig") {
+			status, body = http.StatusOK, `{"type": "file", "encoding": "base64", "content": "`+rootConfig+`"}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})})
+
+	for i := 0; i < 2; i++ {
+		props := loadEditorConfig(context.Background(), client, "owner", "cached-repo", "abc123", "src/app/main.py")
+		if props["indent_size"] != "2" {
+			t.Fatalf("expected indent_size 2, got %v", props)
+		}
+	}
+	// src/app, src and the root are looked up once each, the second load is served from the cache
+	if len(requested) != 3 {
+		t.Errorf("expected 3 requests, got %d: %v", len(requested), requested)
+	}
+}
+
+func TestResolveIndentStyleSkipsEditorConfigWhenDetected(t *testing.T) {
+	client := github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request %s", req.URL.Path)
+		return nil, nil
+	})})
+
+	style := resolveIndentStyle(context.Background(), client, "owner", "repo", "abc123", "main.py", "def f():\n  return 1\n")
+	if style.UseTabs || style.Width != 2 {
+		t.Errorf("expected 2 spaces, got %+v", style)
+	}
+}
@@ -883,3 +883,262 @@ func min(a, b int, rest ...int) int {
 	}
 	return result
 }
+
+// ====== Multiple Suggestion Blocks Tests ======
+
+func TestFindSuggestionBlocks(t *testing.T) {
+	body := strings.Join([]string{
+		"方案一：",
+		"```suggestion",
+		"    a := 1",
+		"```",
+		"方案二：",
+		"```suggestion",
+		"    b := 2",
+		"```",
+		"未结束的块：",
+		"```suggestion",
+		"    c := 3",
+	}, "\n")
+
+	blocks := findSuggestionBlocks(body)
+	if len(blocks) != 3 {
+		t.Fatalf("findSuggestionBlocks() 返回 %d 个块，期望 3 个", len(blocks))
+	}
+
+	// 未结束的块延续到正文末尾
+	expectedContents := []string{"    a := 1\n", "    b := 2\n", "    c := 3\n"}
+	expectedSuffixes := []string{"```", "```", "c := 3"}
+	for i, block := range blocks {
+		if block.Content != expectedContents[i] {
+			t.Errorf("第%d个块内容 = %q，期望 %q", i+1, block.Content, expectedContents[i])
+		}
+		full := body[block.Start:block.End]
+		if !strings.HasPrefix(full, "```suggestion") || !strings.HasSuffix(full, expectedSuffixes[i]) {
+			t.Errorf("第%d个块范围不正确: %q", i+1, full)
+		}
+	}
+}
+
+func TestReindentSuggestionBlocks(t *testing.T) {
+	tests := []struct {
+		name              string
+		body              string
+		targetIndentation string
+		style             indentStyle
+		expected          string
+	}{
+		{
+			name: "两个备选suggestion分别调整",
+			body: strings.Join([]string{
+				"方案一：",
+				"",
+				"```suggestion",
+				"                if err != nil {",
+				"                    return err",
+				"                }",
+				"```",
+				"",
+				"方案二：",
+				"",
+				"```suggestion",
+				"if err != nil {",
+				"  return fmt.Errorf(\"wrap: %w\", err)",
+				"}",
+				"```",
+			}, "\n"),
+			targetIndentation: "        ",
+			style:             indentStyle{UseTabs: false, Width: 4},
+			expected: strings.Join([]string{
+				"方案一：",
+				"",
+				"```suggestion",
+				"        if err != nil {",
+				"            return err",
+				"        }",
+				"```",
+				"",
+				"方案二：",
+				"",
+				"```suggestion",
+				"        if err != nil {",
+				"            return fmt.Errorf(\"wrap: %w\", err)",
+				"        }",
+				"```",
+			}, "\n"),
+		},
+		{
+			name: "suggestion加说明文字",
+			body: strings.Join([]string{
+				"```suggestion",
+				"  x := compute()",
+				"```",
+				"",
+				"这样避免了重复计算。",
+			}, "\n"),
+			targetIndentation: "\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected: strings.Join([]string{
+				"```suggestion",
+				"\tx := compute()",
+				"```",
+				"",
+				"这样避免了重复计算。",
+			}, "\n"),
+		},
+		{
+			name: "首行缩进保留相对缩进",
+			body: strings.Join([]string{
+				"```suggestion",
+				"",
+				"    a()",
+				"        b()",
+				"",
+				"```",
+			}, "\n"),
+			targetIndentation: "\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected: strings.Join([]string{
+				"```suggestion",
+				"\ta()",
+				"\t\tb()",
+				"```",
+			}, "\n"),
+		},
+		{
+			name:              "空suggestion保持不变",
+			body:              "删除这些行：\n```suggestion\n```",
+			targetIndentation: "    ",
+			style:             indentStyle{UseTabs: false, Width: 4},
+			expected:          "删除这些行：\n```suggestion\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := reindentSuggestionBlocks(tt.body, tt.targetIndentation, tt.style)
+			if result != tt.expected {
+				t.Errorf("reindentSuggestionBlocks() 结果不匹配\n期望: %q\n实际: %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// ====== Style-Aware Re-indentation Tests ======
+
+func TestReindentSuggestion(t *testing.T) {
+	tests := []struct {
+		name              string
+		suggestion        string
+		targetIndentation string
+		style             indentStyle
+		expected          string
+	}{
+		{
+			name:              "空格suggestion转换为制表符文件",
+			suggestion:        "    if err != nil {\n        return err\n    }",
+			targetIndentation: "\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected:          "\tif err != nil {\n\t\treturn err\n\t}",
+		},
+		{
+			name:              "2空格suggestion转换为制表符Makefile",
+			suggestion:        "build:\n  go build ./...\n  go vet ./...",
+			targetIndentation: "",
+			style:             indentStyle{UseTabs: true, Width: 8},
+			expected:          "build:\n\tgo build ./...\n\tgo vet ./...",
+		},
+		{
+			name:              "制表符suggestion转换为4空格文件",
+			suggestion:        "\tif x {\n\t\ty()\n\t}",
+			targetIndentation: "        ",
+			style:             indentStyle{UseTabs: false, Width: 4},
+			expected:          "        if x {\n            y()\n        }",
+		},
+		{
+			name:              "2空格suggestion转换为4空格文件",
+			suggestion:        "if x:\n  y()\n  if z:\n    w()",
+			targetIndentation: "    ",
+			style:             indentStyle{UseTabs: false, Width: 4},
+			expected:          "    if x:\n        y()\n        if z:\n            w()",
+		},
+		{
+			name:              "混合缩进的suggestion",
+			suggestion:        "    a()\n\t\tb()",
+			targetIndentation: "\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected:          "\ta()\n\t\tb()",
+		},
+		{
+			name:              "比首行更浅的行",
+			suggestion:        "        x()\n    }",
+			targetIndentation: "\t\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected:          "\t\tx()\n\t}",
+		},
+		{
+			name:              "对齐用的剩余空格保持不变",
+			suggestion:        "if x {\n    foo(a,\n      b)\n}",
+			targetIndentation: "\t",
+			style:             indentStyle{UseTabs: true, Width: 4},
+			expected:          "\tif x {\n\t\tfoo(a,\n\t\t  b)\n\t}",
+		},
+		{
+			name:              "空行保持为空",
+			suggestion:        "a()\n   \nb()",
+			targetIndentation: "  ",
+			style:             indentStyle{UseTabs: false, Width: 2},
+			expected:          "  a()\n\n  b()",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := reindentSuggestion(tt.suggestion, tt.targetIndentation, tt.style)
+			if result != tt.expected {
+				t.Errorf("reindentSuggestion() 结果不匹配\n期望: %q\n实际: %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestIndentationWidth(t *testing.T) {
+	tests := []struct {
+		indentation string
+		tabWidth    int
+		expected    int
+	}{
+		{"", 4, 0},
+		{"    ", 4, 4},
+		{"\t", 4, 4},
+		{"\t\t", 8, 16},
+		{"  \t", 4, 4},
+		{"\t  ", 4, 6},
+	}
+
+	for _, tt := range tests {
+		if result := indentationWidth(tt.indentation, tt.tabWidth); result != tt.expected {
+			t.Errorf("indentationWidth(%q, %d) = %d，期望 %d", tt.indentation, tt.tabWidth, result, tt.expected)
+		}
+	}
+}
+
+func TestRenderIndentation(t *testing.T) {
+	tests := []struct {
+		cols     int
+		style    indentStyle
+		expected string
+	}{
+		{0, indentStyle{UseTabs: true, Width: 4}, ""},
+		{8, indentStyle{UseTabs: true, Width: 4}, "\t\t"},
+		{6, indentStyle{UseTabs: true, Width: 4}, "\t  "},
+		{6, indentStyle{UseTabs: false, Width: 2}, "      "},
+		{-2, indentStyle{UseTabs: false, Width: 2}, ""},
+	}
+
+	for _, tt := range tests {
+		if result := renderIndentation(tt.cols, tt.style); result != tt.expected {
+			t.Errorf("renderIndentation(%d, %+v) = %q，期望 %q", tt.cols, tt.style, result, tt.expected)
+		}
+	}
+}
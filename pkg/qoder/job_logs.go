@@ -0,0 +1,309 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// maxJobLogBytes bounds the size of a downloaded job log
+	maxJobLogBytes = 32 << 20
+
+	// jobLogTimeout bounds a job log download, retries included
+	jobLogTimeout = 2 * time.Minute
+)
+
+var (
+	// logTimestampPattern matches the timestamp GitHub Actions puts before every log line
+	logTimestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z ?`)
+
+	// ansiPattern matches ANSI escape sequences such as colors
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+)
+
+// cleanJobLog splits a job log into lines without timestamps and ANSI escape sequences
+func cleanJobLog(raw string) []string {
+	raw = strings.TrimPrefix(raw, "\uFEFF")
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	raw = strings.TrimRight(raw, "\n")
+	if raw == "" {
+		return nil
+	}
+
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		line = logTimestampPattern.ReplaceAllString(line, "")
+		line = ansiPattern.ReplaceAllString(line, "")
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// isLogError reports whether a log line is an error reported by a step
+func isLogError(line string) bool {
+	return strings.HasPrefix(line, "##[error]")
+}
+
+// isStepStart reports whether a log line starts a step
+func isStepStart(line string) bool {
+	return strings.HasPrefix(line, "##[group]Run ")
+}
+
+// readableLogLine replaces the workflow command markers of a log line with plain text
+// Returns false for lines that only carry a marker
+func readableLogLine(line string) (string, bool) {
+	switch {
+	case line == "##[endgroup]":
+		return "", false
+	case strings.HasPrefix(line, "##[group]"):
+		return strings.TrimPrefix(line, "##[group]"), true
+	case strings.HasPrefix(line, "##[error]"):
+		return "Error: " + strings.TrimPrefix(line, "##[error]"), true
+	case strings.HasPrefix(line, "##[warning]"):
+		return "Warning: " + strings.TrimPrefix(line, "##[warning]"), true
+	case strings.HasPrefix(line, "##[notice]"):
+		return "Notice: " + strings.TrimPrefix(line, "##[notice]"), true
+	}
+	return line, true
+}
+
+// logExcerpt is the part of a job log returned to the agent
+type logExcerpt struct {
+	Step      string `json:"step,omitempty"` // The step's command, from its "Run" line
+	StartLine int    `json:"start_line"`     // 1-based lines of the cleaned log the excerpt covers
+	EndLine   int    `json:"end_line"`
+	Words     int    `json:"words"`
+	Truncated bool   `json:"truncated"`
+	Text      string `json:"text"`
+}
+
+// failingSection locates the step that failed: from the start of the step holding the last error to that
+// error. Without errors it is the last step, or the whole log if it has no steps. Returns 0-based bounds.
+func failingSection(lines []string) (start, end int) {
+	end = len(lines) - 1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isLogError(lines[i]) {
+			end = i
+			break
+		}
+	}
+
+	for i := end; i >= 0; i-- {
+		if isStepStart(lines[i]) {
+			return i, end
+		}
+	}
+	return 0, end
+}
+
+// excerptLog renders lines[start:end+1] within a word budget, counting words with the diff compressor
+// Over budget, the step's first line and its error lines are kept first, then as many lines as fit from
+// the end, which is where a failure is explained; omitted lines are marked.
+func excerptLog(compressor *DiffCompressor, lines []string, start, end, maxWords int) logExcerpt {
+	excerpt := logExcerpt{StartLine: start + 1, EndLine: end + 1}
+	if len(lines) == 0 || start > end {
+		return excerpt
+	}
+	if isStepStart(lines[start]) {
+		excerpt.Step = strings.TrimPrefix(lines[start], "##[group]Run ")
+	}
+
+	words := make([]int, end-start+1)
+	total := 0
+	for i := range words {
+		words[i] = compressor.countWords(lines[start+i])
+		total += words[i]
+	}
+
+	keep := make([]bool, len(words))
+	budget := maxWords
+	take := func(i int) bool {
+		if keep[i] {
+			return true
+		}
+		if words[i] > budget {
+			return false
+		}
+		keep[i] = true
+		budget -= words[i]
+		return true
+	}
+
+	if total <= maxWords {
+		for i := range keep {
+			keep[i] = true
+		}
+		budget -= total
+	} else {
+		excerpt.Truncated = true
+
+		take(0)
+		// Error lines get up to half the budget, so the tail still has room
+		errorBudget := budget / 2
+		for i := range words {
+			if isLogError(lines[start+i]) && words[i] <= errorBudget {
+				errorBudget -= words[i]
+				take(i)
+			}
+		}
+		for i := len(words) - 1; i > 0; i-- {
+			if !take(i) {
+				break
+			}
+		}
+	}
+
+	var sb strings.Builder
+	omitted := 0
+	flushOmitted := func() {
+		if omitted > 0 {
+			fmt.Fprintf(&sb, "... (%d lines omitted) ...\n", omitted)
+			omitted = 0
+		}
+	}
+	for i, kept := range keep {
+		if !kept {
+			omitted++
+			continue
+		}
+		flushOmitted()
+		if line, ok := readableLogLine(lines[start+i]); ok {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+	}
+	flushOmitted()
+
+	excerpt.Words = maxWords - budget
+	excerpt.Text = strings.TrimRight(sb.String(), "\n")
+	return excerpt
+}
+
+// newJobLogClient creates the HTTP client downloading job logs, with a timeout and the retries of the
+// GitHub clients; the signed log URLs carry their own authorization, so it sends no GitHub token
+func newJobLogClient() *http.Client {
+	return &http.Client{
+		Transport: newRetryTransportFromEnv(http.DefaultTransport, nil),
+		Timeout:   jobLogTimeout,
+	}
+}
+
+// downloadJobLog downloads a job log from the signed URL the API redirects to
+func downloadJobLog(ctx context.Context, client *http.Client, logURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJobLogBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetJobLogs creates a tool to read the log of a GitHub Actions job, focused on why it failed
+func GetJobLogs(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "get_job_logs"
+	description := "Get the log of a GitHub Actions job, without timestamps and color codes. By default returns only the failing step, from its command up to the last error, trimmed to a word budget that keeps the error lines and the end of the step. Get job IDs from get_pull_request_checks."
+	logClient := newJobLogClient()
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("job_id",
+				mcp.Required(),
+				mcp.Description("ID of the Actions job, the id of its check run in get_pull_request_checks"),
+			),
+			mcp.WithBoolean("full",
+				mcp.Description("Return the whole log, trimmed to the word budget from the end, instead of the failing step (default: false)"),
+			),
+			mcp.WithNumber("max_words",
+				mcp.Description("Word budget of the returned log (default: the per-file limit of PR diffs, PR_DIFF_MAX_FILE_WORDS or 5000)"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				JobID    int64 `mapstructure:"job_id"`
+				Full     bool  `mapstructure:"full"`
+				MaxWords int   `mapstructure:"max_words"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if params.JobID == 0 {
+				return mcp.NewToolResultError("job_id is required"), nil
+			}
+			// A job log gets the same budget as one file of a PR diff
+			compressor := NewDiffCompressor()
+			if params.MaxWords <= 0 {
+				params.MaxWords = compressor.maxFileWords
+			}
+
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			job, _, err := client.Actions.GetWorkflowJobByID(ctx, owner, repo, params.JobID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get job: %v", err)), nil
+			}
+
+			logURL, _, err := client.Actions.GetWorkflowJobLogs(ctx, owner, repo, params.JobID, 1)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get job log URL: %v", err)), nil
+			}
+			raw, err := downloadJobLog(ctx, logClient, logURL.String())
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to download job log: %v", err)), nil
+			}
+
+			lines := cleanJobLog(raw)
+			start, end := 0, len(lines)-1
+			if !params.Full {
+				start, end = failingSection(lines)
+			}
+			excerpt := excerptLog(compressor, lines, start, end, params.MaxWords)
+
+			var failedSteps []string
+			for _, step := range job.Steps {
+				if step.GetConclusion() == "failure" {
+					failedSteps = append(failedSteps, step.GetName())
+				}
+			}
+
+			result := map[string]interface{}{
+				"job_id":       job.GetID(),
+				"name":         job.GetName(),
+				"status":       job.GetStatus(),
+				"conclusion":   job.GetConclusion(),
+				"url":          job.GetHTMLURL(),
+				"failed_steps": failedSteps,
+				"total_lines":  len(lines),
+				"log":          excerpt,
+			}
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal job log: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
@@ -0,0 +1,150 @@
+package qoder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanJobLog(t *testing.T) {
+	raw := "\uFEFF2024-05-01T10:00:00.1234567Z ##[group]Run go test ./...\r\n" +
+		"2024-05-01T10:00:01.0000000Z \x1b[36;1mgo test ./...\x1b[0m\r\n" +
+		"2024-05-01T10:00:02.0000000Z \x1b[31mFAIL\x1b[0m  pkg/foo   \r\n" +
+		"no timestamp here\n"
+
+	expected := []string{
+		"##[group]Run go test ./...",
+		"go test ./...",
+		"FAIL  pkg/foo",
+		"no timestamp here",
+	}
+	if lines := cleanJobLog(raw); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+
+	if lines := cleanJobLog(""); lines != nil {
+		t.Errorf("expected no lines, got %q", lines)
+	}
+}
+
+func TestFailingSection(t *testing.T) {
+	testCases := []struct {
+		name          string
+		lines         []string
+		expectedStart int
+		expectedEnd   int
+	}{
+		{
+			name: "step with the last error",
+			lines: []string{
+				"##[group]Run actions/checkout@v4", "checked out", "##[endgroup]",
+				"##[group]Run go build", "ok", "##[endgroup]",
+				"##[group]Run go test", "--- FAIL: TestX", "##[error]Process completed with exit code 1.",
+				"Post job cleanup.",
+			},
+			expectedStart: 6,
+			expectedEnd:   8,
+		},
+		{
+			name:          "no errors takes the last step",
+			lines:         []string{"##[group]Run a", "x", "##[group]Run b", "y", "z"},
+			expectedStart: 2,
+			expectedEnd:   4,
+		},
+		{
+			name:          "no steps takes the whole log",
+			lines:         []string{"a", "##[error]boom", "b"},
+			expectedStart: 0,
+			expectedEnd:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := failingSection(tc.lines)
+			if start != tc.expectedStart || end != tc.expectedEnd {
+				t.Errorf("expected [%d, %d], got [%d, %d]", tc.expectedStart, tc.expectedEnd, start, end)
+			}
+		})
+	}
+}
+
+func TestExcerptLog(t *testing.T) {
+	t.Run("within budget", func(t *testing.T) {
+		lines := []string{"##[group]Run go test", "running tests", "##[endgroup]", "##[error]exit code 1"}
+		excerpt := excerptLog(NewDiffCompressor(), lines, 0, 3, 100)
+
+		expectedText := "Run go test\nrunning tests\nError: exit code 1"
+		if excerpt.Text != expectedText {
+			t.Errorf("expected %q, got %q", expectedText, excerpt.Text)
+		}
+		if excerpt.Truncated || excerpt.Step != "go test" || excerpt.StartLine != 1 || excerpt.EndLine != 4 {
+			t.Errorf("unexpected excerpt %+v", excerpt)
+		}
+	})
+
+	t.Run("over budget keeps header, errors and tail", func(t *testing.T) {
+		lines := []string{"##[group]Run make check"}
+		for i := 0; i < 50; i++ {
+			lines = append(lines, "noise line number "+strings.Repeat("x", i%3+1))
+		}
+		lines = append(lines, "##[error]main.go:3: undefined: foo")
+		for i := 0; i < 50; i++ {
+			lines = append(lines, "more noise here "+strings.Repeat("y", i%3+1))
+		}
+		lines = append(lines, "tail one", "##[error]Process completed with exit code 2.")
+
+		excerpt := excerptLog(NewDiffCompressor(), lines, 0, len(lines)-1, 30)
+		if !excerpt.Truncated {
+			t.Fatal("expected the excerpt to be truncated")
+		}
+		if excerpt.Words > 30 {
+			t.Errorf("expected at most 30 words, got %d", excerpt.Words)
+		}
+		for _, s := range []string{"Run make check", "Error: main.go:3: undefined: foo", "tail one", "Error: Process completed with exit code 2.", "lines omitted"} {
+			if !strings.Contains(excerpt.Text, s) {
+				t.Errorf("expected %q in excerpt:\n%s", s, excerpt.Text)
+			}
+		}
+		if strings.Index(excerpt.Text, "undefined: foo") > strings.Index(excerpt.Text, "tail one") {
+			t.Errorf("expected lines in log order:\n%s", excerpt.Text)
+		}
+	})
+
+	t.Run("empty log", func(t *testing.T) {
+		if excerpt := excerptLog(NewDiffCompressor(), nil, 0, -1, 100); excerpt.Text != "" || excerpt.Truncated {
+			t.Errorf("expected an empty excerpt, got %+v", excerpt)
+		}
+	})
+}
+
+func TestDownloadJobLog(t *testing.T) {
+	requests := 0
+	transport := newRetryTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if req.Header.Get("Authorization") != "" {
+			t.Error("expected no authorization header")
+		}
+		if requests == 1 {
+			return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("log line\n")), Request: req}, nil
+	}), nil, 2, time.Second)
+	transport.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+	raw, err := downloadJobLog(context.Background(), &http.Client{Transport: transport}, "https://logs.example.com/job/1?sig=abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw != "log line\n" || requests != 2 {
+		t.Errorf("expected the log after a retry, got %q after %d requests", raw, requests)
+	}
+
+	if client := newJobLogClient(); client.Timeout != jobLogTimeout {
+		t.Errorf("expected a %s timeout, got %s", jobLogTimeout, client.Timeout)
+	}
+}
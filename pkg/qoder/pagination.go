@@ -0,0 +1,135 @@
+package qoder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/go-github/v73/github"
+)
+
+const (
+	// maxPerPage is the largest page size the GitHub REST API accepts
+	maxPerPage = 100
+
+	// maxConcurrentPages bounds the number of pages fetched at the same time in "all" mode
+	maxConcurrentPages = 4
+)
+
+// pageFetcher fetches one page of a REST list endpoint
+type pageFetcher[T any] func(ctx context.Context, opts github.ListOptions) ([]T, *github.Response, error)
+
+// fetchAllPages fetches every page of a REST list endpoint
+// The first page tells how many pages there are; the rest are fetched concurrently, at most
+// maxConcurrentPages at a time, and returned in page order. The first error cancels the remaining pages.
+func fetchAllPages[T any](ctx context.Context, fetch pageFetcher[T]) ([]T, error) {
+	first, resp, err := fetch(ctx, github.ListOptions{Page: 1, PerPage: maxPerPage})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.NextPage == 0 {
+		return first, nil
+	}
+	if resp.LastPage == 0 {
+		// Without a last page link, walk the pages one after another
+		return fetchRemainingPagesSequentially(ctx, fetch, first, resp.NextPage)
+	}
+
+	pages := make([][]T, resp.LastPage)
+	pages[0] = first
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		slots    = make(chan struct{}, maxConcurrentPages)
+	)
+	for page := 2; page <= resp.LastPage; page++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+
+			items, _, err := fetch(ctx, github.ListOptions{Page: page, PerPage: maxPerPage})
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			pages[page-1] = items
+		}(page)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []T
+	for _, items := range pages {
+		all = append(all, items...)
+	}
+	return all, nil
+}
+
+// fetchRemainingPagesSequentially follows NextPage from the given page until the last one
+func fetchRemainingPagesSequentially[T any](ctx context.Context, fetch pageFetcher[T], all []T, nextPage int) ([]T, error) {
+	for nextPage > 0 {
+		items, resp, err := fetch(ctx, github.ListOptions{Page: nextPage, PerPage: maxPerPage})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if resp == nil {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return all, nil
+}
+
+// pageTotal returns the total number of items of a list endpoint when a page response reveals it
+// It is exact on a non-empty last page, or from the last page link when pages hold one item; otherwise
+// ok is false and the caller must count another way
+func pageTotal(page, perPage, count int, resp *github.Response) (int, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if resp.NextPage > 0 {
+		if perPage == 1 && resp.LastPage > 0 {
+			return resp.LastPage, true
+		}
+		return 0, false
+	}
+	// An empty page past the end says nothing about how many items come before it
+	if count == 0 && page > 1 {
+		return 0, false
+	}
+	return (page-1)*perPage + count, true
+}
+
+// countItems counts the items of a list endpoint by requesting one item per page, so the last page
+// number in the Link header is the total
+func countItems[T any](ctx context.Context, fetch pageFetcher[T]) (int, error) {
+	items, resp, err := fetch(ctx, github.ListOptions{Page: 1, PerPage: 1})
+	if err != nil {
+		return 0, err
+	}
+	if resp != nil && resp.LastPage > 0 {
+		return resp.LastPage, nil
+	}
+	return len(items), nil
+}
@@ -0,0 +1,135 @@
+package qoder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// fakePages serves numbered items in pages and records the highest number of concurrent fetches
+type fakePages struct {
+	total       int
+	linkLast    bool // Whether responses carry a last page link
+	failPage    int
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *fakePages) fetch(_ context.Context, opts github.ListOptions) ([]int, *github.Response, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if opts.Page == f.failPage {
+		return nil, nil, errors.New("boom")
+	}
+
+	lastPage := (f.total + opts.PerPage - 1) / opts.PerPage
+	var items []int
+	for i := (opts.Page - 1) * opts.PerPage; i < min(opts.Page*opts.PerPage, f.total); i++ {
+		items = append(items, i)
+	}
+
+	resp := &github.Response{}
+	if opts.Page < lastPage {
+		resp.NextPage = opts.Page + 1
+		if f.linkLast {
+			resp.LastPage = lastPage
+		}
+	}
+	return items, resp, nil
+}
+
+func TestFetchAllPages(t *testing.T) {
+	testCases := []struct {
+		name     string
+		total    int
+		linkLast bool
+	}{
+		{name: "empty", total: 0, linkLast: true},
+		{name: "single page", total: 42, linkLast: true},
+		{name: "many pages concurrently", total: 1234, linkLast: true},
+		{name: "no last page link", total: 345, linkLast: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pages := &fakePages{total: tc.total, linkLast: tc.linkLast}
+			items, err := fetchAllPages(context.Background(), pages.fetch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != tc.total {
+				t.Fatalf("expected %d items, got %d", tc.total, len(items))
+			}
+			for i, item := range items {
+				if item != i {
+					t.Fatalf("expected items in page order, item %d is %d", i, item)
+				}
+			}
+			if pages.maxInFlight > maxConcurrentPages {
+				t.Errorf("expected at most %d concurrent fetches, got %d", maxConcurrentPages, pages.maxInFlight)
+			}
+		})
+	}
+}
+
+func TestFetchAllPagesError(t *testing.T) {
+	pages := &fakePages{total: 1000, linkLast: true, failPage: 7}
+	if _, err := fetchAllPages(context.Background(), pages.fetch); err == nil {
+		t.Fatal("expected an error when a page fails")
+	}
+}
+
+func TestPageTotal(t *testing.T) {
+	testCases := []struct {
+		name          string
+		page          int
+		perPage       int
+		count         int
+		nextPage      int
+		lastPage      int
+		expected      int
+		expectedKnown bool
+	}{
+		{name: "only page", page: 1, perPage: 30, count: 12, expected: 12, expectedKnown: true},
+		{name: "last page", page: 3, perPage: 30, count: 5, expected: 65, expectedKnown: true},
+		{name: "empty list", page: 1, perPage: 30, count: 0, expected: 0, expectedKnown: true},
+		{name: "more pages", page: 1, perPage: 30, count: 30, nextPage: 2, expectedKnown: false},
+		{name: "past the end", page: 9, perPage: 30, count: 0, expectedKnown: false},
+		{name: "last page link of single items", page: 1, perPage: 1, count: 1, nextPage: 2, lastPage: 57, expected: 57, expectedKnown: true},
+		{name: "last page link of full pages", page: 1, perPage: 30, count: 30, nextPage: 2, lastPage: 3, expectedKnown: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			total, known := pageTotal(tc.page, tc.perPage, tc.count, &github.Response{NextPage: tc.nextPage, LastPage: tc.lastPage})
+			if known != tc.expectedKnown || total != tc.expected {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tc.expected, tc.expectedKnown, total, known)
+			}
+		})
+	}
+}
+
+func TestCountItems(t *testing.T) {
+	for _, total := range []int{0, 1, 57} {
+		pages := &fakePages{total: total, linkLast: true}
+		count, err := countItems(context.Background(), pages.fetch)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != total {
+			t.Errorf("expected %d items, got %d", total, count)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shurcooL/githubv4"
+)
+
+// noPendingReviewMessage is the error of tools that need the current user's pending review when there is none
+const noPendingReviewMessage = "No pending review found for the current user"
+
+// pendingReview is the current user's pending review on a pull request
+type pendingReview struct {
+	ID           githubv4.ID
+	DatabaseID   int64
+	URL          string
+	CommitID     string
+	CommentCount int
+	Author       string // Login of the current user
+}
+
+// findViewerPendingReview gets the current user's pending review on a pull request
+// Returns nil if the user has no pending review
+func findViewerPendingReview(ctx context.Context, client *githubv4.Client, owner, repo string, pullNumber int) (*pendingReview, error) {
+	var getViewerQuery struct {
+		Viewer struct {
+			Login githubv4.String
+		}
+	}
+	if err := client.Query(ctx, &getViewerQuery, nil); err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	var getPendingReviewQuery struct {
+		Repository struct {
+			PullRequest struct {
+				Reviews struct {
+					Nodes []struct {
+						ID         githubv4.ID
+						DatabaseID int64
+						URL        githubv4.URI
+						Commit     struct {
+							OID githubv4.String `graphql:"oid"`
+						}
+						Comments struct {
+							TotalCount githubv4.Int
+						}
+					}
+				} `graphql:"reviews(first: 1, author: $author, states: PENDING)"`
+			} `graphql:"pullRequest(number: $prNum)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	vars := map[string]any{
+		"author": getViewerQuery.Viewer.Login,
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"prNum":  githubv4.Int(pullNumber),
+	}
+	if err := client.Query(ctx, &getPendingReviewQuery, vars); err != nil {
+		return nil, fmt.Errorf("failed to get pending review: %w", err)
+	}
+
+	nodes := getPendingReviewQuery.Repository.PullRequest.Reviews.Nodes
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	return &pendingReview{
+		ID:           nodes[0].ID,
+		DatabaseID:   nodes[0].DatabaseID,
+		URL:          nodes[0].URL.String(),
+		CommitID:     string(nodes[0].Commit.OID),
+		CommentCount: int(nodes[0].Comments.TotalCount),
+		Author:       string(getViewerQuery.Viewer.Login),
+	}, nil
+}
+
+// DeletePendingReview creates a tool to delete the requester's pending pull request review
+func DeletePendingReview(getClient GetClientFn, getGQLClient GetGQLClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "delete_pending_review"
+	description := "Delete the requester's pending pull request review together with all of its comments. Use this to start a review over, e.g. after an interrupted run left a half-built review behind."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number", mcp.Required(), mcp.Description("Pull request number")),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				PullNumber int32 `mapstructure:"pull_number"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			gqlClient, err := getGQLClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+			}
+
+			review, err := findViewerPendingReview(ctx, gqlClient, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if review == nil {
+				return mcp.NewToolResultError(noPendingReviewMessage), nil
+			}
+
+			restClient, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub REST client: %w", err)
+			}
+
+			if _, _, err := restClient.PullRequests.DeletePendingReview(ctx, owner, repo, int(params.PullNumber), review.DatabaseID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to delete pending review: %v", err)), nil
+			}
+
+			result := map[string]interface{}{
+				"review_id":        review.DatabaseID,
+				"deleted":          true,
+				"deleted_comments": review.CommentCount,
+			}
+			resultJSON, _ := json.Marshal(result)
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
@@ -0,0 +1,178 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// prCheck is a check run or commit status on a commit
+type prCheck struct {
+	ID          int64  `json:"id,omitempty"` // Check run ID; for GitHub Actions also the job ID to pass to get_job_logs
+	Kind        string `json:"kind"`         // check_run or status
+	Name        string `json:"name"`
+	App         string `json:"app,omitempty"`
+	Status      string `json:"status"`
+	Conclusion  string `json:"conclusion,omitempty"`
+	Outcome     string `json:"outcome"`
+	Title       string `json:"title,omitempty"`
+	URL         string `json:"url,omitempty"`
+	StartedAt   string `json:"started_at,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+// newCheckRunCheck converts a check run
+func newCheckRunCheck(checkRun *github.CheckRun) prCheck {
+	check := prCheck{
+		ID:         checkRun.GetID(),
+		Kind:       "check_run",
+		Name:       checkRun.GetName(),
+		App:        checkRun.GetApp().GetSlug(),
+		Status:     checkRun.GetStatus(),
+		Conclusion: checkRun.GetConclusion(),
+		Outcome:    checkRunOutcome(strings.ToUpper(checkRun.GetStatus()), strings.ToUpper(checkRun.GetConclusion())),
+		Title:      checkRun.GetOutput().GetTitle(),
+		URL:        checkRun.GetHTMLURL(),
+	}
+	if checkRun.StartedAt != nil {
+		check.StartedAt = checkRun.StartedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	if checkRun.CompletedAt != nil {
+		check.CompletedAt = checkRun.CompletedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return check
+}
+
+// newStatusCheck converts a commit status
+func newStatusCheck(status *github.RepoStatus) prCheck {
+	return prCheck{
+		Kind:    "status",
+		Name:    status.GetContext(),
+		Status:  status.GetState(),
+		Outcome: statusContextOutcome(strings.ToUpper(status.GetState())),
+		Title:   status.GetDescription(),
+		URL:     status.GetTargetURL(),
+	}
+}
+
+// checkOutcomeOrder sorts failing checks first, then running ones
+var checkOutcomeOrder = map[string]int{checkFailed: 0, checkPending: 1, checkPassed: 2, checkSkipped: 3}
+
+// sortChecks sorts checks by outcome, failing first, then by name
+func sortChecks(checks []prCheck) {
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checkOutcomeOrder[checks[i].Outcome] != checkOutcomeOrder[checks[j].Outcome] {
+			return checkOutcomeOrder[checks[i].Outcome] < checkOutcomeOrder[checks[j].Outcome]
+		}
+		return checks[i].Name < checks[j].Name
+	})
+}
+
+// checksState combines check outcomes into one state: failure if any check failed, pending if any is
+// still running, success otherwise; "" if there are no checks
+func checksState(summary overviewChecks) string {
+	switch {
+	case summary.Total == 0:
+		return ""
+	case summary.Failed > 0:
+		return "failure"
+	case summary.Pending > 0:
+		return "pending"
+	default:
+		return "success"
+	}
+}
+
+// GetPullRequestChecks creates a tool to list the check runs and commit statuses of a pull request's head commit
+func GetPullRequestChecks(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "get_pull_request_checks"
+	description := "List the CI check runs and commit statuses of a pull request's head commit, failing checks first, with a combined state and counts by outcome. For GitHub Actions check runs, the id is the job ID to pass to get_job_logs to see why a check failed."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number",
+				mcp.Required(),
+				mcp.Description("Pull request number"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				PullNumber int `mapstructure:"pull_number"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			pr, _, err := client.PullRequests.Get(ctx, owner, repo, params.PullNumber)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR: %v", err)), nil
+			}
+			headSHA := pr.GetHead().GetSHA()
+
+			checkRuns, err := fetchAllPages(ctx, func(ctx context.Context, opts github.ListOptions) ([]*github.CheckRun, *github.Response, error) {
+				result, resp, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, headSHA, &github.ListCheckRunsOptions{ListOptions: opts})
+				if err != nil {
+					return nil, resp, err
+				}
+				return result.CheckRuns, resp, nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to list check runs: %v", err)), nil
+			}
+
+			// The combined status holds the latest status of each context
+			statuses, err := fetchAllPages(ctx, func(ctx context.Context, opts github.ListOptions) ([]*github.RepoStatus, *github.Response, error) {
+				combined, resp, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, headSHA, &opts)
+				if err != nil {
+					return nil, resp, err
+				}
+				return combined.Statuses, resp, nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get commit statuses: %v", err)), nil
+			}
+
+			checks := make([]prCheck, 0, len(checkRuns)+len(statuses))
+			for _, checkRun := range checkRuns {
+				checks = append(checks, newCheckRunCheck(checkRun))
+			}
+			for _, status := range statuses {
+				checks = append(checks, newStatusCheck(status))
+			}
+			sortChecks(checks)
+
+			outcomes := make([]overviewCheck, 0, len(checks))
+			for _, check := range checks {
+				outcomes = append(outcomes, overviewCheck{Name: check.Name, Outcome: check.Outcome})
+			}
+			summary := summarizeChecks("", outcomes)
+
+			result := map[string]interface{}{
+				"head_sha": headSHA,
+				"state":    checksState(summary),
+				"total":    summary.Total,
+				"passed":   summary.Passed,
+				"failed":   summary.Failed,
+				"pending":  summary.Pending,
+				"skipped":  summary.Skipped,
+				"checks":   checks,
+			}
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal checks: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
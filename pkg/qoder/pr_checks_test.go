@@ -0,0 +1,80 @@
+package qoder
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestNewCheckRunCheck(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     string
+		conclusion string
+		expected   string
+	}{
+		{name: "success", status: "completed", conclusion: "success", expected: checkPassed},
+		{name: "failure", status: "completed", conclusion: "failure", expected: checkFailed},
+		{name: "running", status: "in_progress", expected: checkPending},
+		{name: "skipped", status: "completed", conclusion: "skipped", expected: checkSkipped},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkRun := &github.CheckRun{ID: github.Ptr(int64(1)), Status: github.Ptr(tc.status)}
+			if tc.conclusion != "" {
+				checkRun.Conclusion = github.Ptr(tc.conclusion)
+			}
+			if check := newCheckRunCheck(checkRun); check.Outcome != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, check.Outcome)
+			}
+		})
+	}
+}
+
+func TestNewStatusCheck(t *testing.T) {
+	status := &github.RepoStatus{Context: github.Ptr("ci/jenkins"), State: github.Ptr("error"), TargetURL: github.Ptr("https://ci.example.com/1")}
+	check := newStatusCheck(status)
+	if check.Kind != "status" || check.Name != "ci/jenkins" || check.Outcome != checkFailed || check.URL != "https://ci.example.com/1" {
+		t.Errorf("unexpected check %+v", check)
+	}
+}
+
+func TestSortChecks(t *testing.T) {
+	checks := []prCheck{
+		{Name: "lint", Outcome: checkPassed},
+		{Name: "e2e", Outcome: checkPending},
+		{Name: "unit", Outcome: checkFailed},
+		{Name: "docs", Outcome: checkSkipped},
+		{Name: "build", Outcome: checkFailed},
+	}
+	sortChecks(checks)
+
+	expected := []string{"build", "unit", "e2e", "lint", "docs"}
+	for i, name := range expected {
+		if checks[i].Name != name {
+			t.Fatalf("expected order %v, got %+v", expected, checks)
+		}
+	}
+}
+
+func TestChecksState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		summary  overviewChecks
+		expected string
+	}{
+		{name: "no checks", summary: overviewChecks{}, expected: ""},
+		{name: "failure wins", summary: overviewChecks{Total: 3, Failed: 1, Pending: 1, Passed: 1}, expected: "failure"},
+		{name: "pending", summary: overviewChecks{Total: 2, Pending: 1, Passed: 1}, expected: "pending"},
+		{name: "success", summary: overviewChecks{Total: 2, Passed: 1, Skipped: 1}, expected: "success"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if state := checksState(tc.summary); state != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, state)
+			}
+		})
+	}
+}
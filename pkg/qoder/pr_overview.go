@@ -0,0 +1,436 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shurcooL/githubv4"
+)
+
+// Outcomes of a check in the overview
+const (
+	checkPassed  = "passed"
+	checkFailed  = "failed"
+	checkPending = "pending"
+	checkSkipped = "skipped"
+)
+
+// pullRequestOverview is a compact summary of a pull request and its review state
+type pullRequestOverview struct {
+	Number             int              `json:"number"`
+	Title              string           `json:"title"`
+	State              string           `json:"state"`
+	Draft              bool             `json:"draft,omitempty"`
+	Author             string           `json:"author"`
+	Head               branchDTO        `json:"head"`
+	Base               branchDTO        `json:"base"`
+	URL                string           `json:"url"`
+	Mergeable          string           `json:"mergeable"`
+	ReviewDecision     string           `json:"review_decision,omitempty"`
+	Labels             []string         `json:"labels,omitempty"`
+	Assignees          []string         `json:"assignees,omitempty"`
+	RequestedReviewers []string         `json:"requested_reviewers,omitempty"`
+	Reviews            []overviewReview `json:"reviews"`
+	Threads            overviewThreads  `json:"threads"`
+	Checks             overviewChecks   `json:"checks"`
+	Files              overviewFiles    `json:"files"`
+}
+
+// overviewReview is the latest review of one reviewer
+type overviewReview struct {
+	Author      string `json:"author"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at,omitempty"`
+	Stale       bool   `json:"stale,omitempty"` // The review is on an older commit than the head
+}
+
+// overviewThreads counts the review threads of a pull request
+type overviewThreads struct {
+	Total              int `json:"total"`
+	Unresolved         int `json:"unresolved"`
+	UnresolvedOutdated int `json:"unresolved_outdated"`
+}
+
+// overviewCheck is a check run or commit status on the head commit
+type overviewCheck struct {
+	Name    string `json:"name"`
+	Outcome string `json:"outcome"`
+	URL     string `json:"url,omitempty"`
+}
+
+// overviewChecks summarizes the checks on the head commit, listing those that did not pass
+type overviewChecks struct {
+	State   string          `json:"state"` // Status rollup state, empty if there are no checks
+	Total   int             `json:"total"`
+	Passed  int             `json:"passed"`
+	Failed  int             `json:"failed"`
+	Pending int             `json:"pending"`
+	Skipped int             `json:"skipped"`
+	Failing []overviewCheck `json:"failing,omitempty"`
+	Running []overviewCheck `json:"running,omitempty"`
+}
+
+// overviewFile is the change stats of one file
+type overviewFile struct {
+	Path       string `json:"path"`
+	ChangeType string `json:"change_type"`
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+}
+
+// overviewFiles summarizes the changed files; List holds at most the first 100
+type overviewFiles struct {
+	Count     int            `json:"count"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	List      []overviewFile `json:"list"`
+}
+
+// checkRunOutcome classifies a check run by its status and conclusion
+func checkRunOutcome(status, conclusion string) string {
+	if status != "COMPLETED" {
+		return checkPending
+	}
+	switch conclusion {
+	case "SUCCESS", "NEUTRAL":
+		return checkPassed
+	case "SKIPPED":
+		return checkSkipped
+	default:
+		// FAILURE, CANCELLED, TIMED_OUT, ACTION_REQUIRED, STARTUP_FAILURE, STALE
+		return checkFailed
+	}
+}
+
+// statusContextOutcome classifies a commit status by its state
+func statusContextOutcome(state string) string {
+	switch state {
+	case "SUCCESS":
+		return checkPassed
+	case "PENDING", "EXPECTED":
+		return checkPending
+	default:
+		return checkFailed
+	}
+}
+
+// summarizeChecks counts checks by outcome and lists the failing and running ones
+func summarizeChecks(state string, checks []overviewCheck) overviewChecks {
+	summary := overviewChecks{State: state, Total: len(checks)}
+	for _, check := range checks {
+		switch check.Outcome {
+		case checkPassed:
+			summary.Passed++
+		case checkSkipped:
+			summary.Skipped++
+		case checkPending:
+			summary.Pending++
+			summary.Running = append(summary.Running, check)
+		default:
+			summary.Failed++
+			summary.Failing = append(summary.Failing, check)
+		}
+	}
+	return summary
+}
+
+// summarizeThreads counts review threads, skipping the viewer's pending ones that nobody else can see yet
+func summarizeThreads(threads []reviewThread) overviewThreads {
+	var summary overviewThreads
+	for _, thread := range threads {
+		if thread.CommentState == "PENDING" {
+			continue
+		}
+		summary.Total++
+		if !thread.IsResolved {
+			summary.Unresolved++
+			if thread.IsOutdated {
+				summary.UnresolvedOutdated++
+			}
+		}
+	}
+	return summary
+}
+
+// GetPullRequestOverview creates a tool to get a compact overview of a pull request in one call
+func GetPullRequestOverview(getGQLClient GetGQLClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "get_pull_request_overview"
+	description := "Get a compact overview of a pull request in a single call: metadata, labels, requested reviewers, the latest review of each reviewer, review thread counts, the check status of the head commit and file change stats. Use this first when starting a review."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number",
+				mcp.Required(),
+				mcp.Description("Pull request number"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				PullNumber int32 `mapstructure:"pull_number"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			client, err := getGQLClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+			}
+
+			overview, err := fetchPullRequestOverview(ctx, client, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return NewGitHubGraphQLErrorResponse(ctx,
+					"failed to get pull request overview",
+					err,
+				), nil
+			}
+
+			overviewJSON, err := json.Marshal(overview)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal overview: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(overviewJSON)), nil
+		}
+}
+
+// fetchPullRequestOverview gets the overview of a pull request with one GraphQL query
+// Only pull requests with more than 100 review threads need a second query to count them all
+func fetchPullRequestOverview(ctx context.Context, client *githubv4.Client, owner, repo string, pullNumber int) (*pullRequestOverview, error) {
+	type checkRun struct {
+		Name       githubv4.String
+		Status     githubv4.String
+		Conclusion *githubv4.String
+		DetailsURL *githubv4.URI `graphql:"detailsUrl"`
+	}
+	type statusContext struct {
+		Context   githubv4.String
+		State     githubv4.String
+		TargetURL *githubv4.URI `graphql:"targetUrl"`
+	}
+
+	var query struct {
+		Repository struct {
+			PullRequest struct {
+				Number         githubv4.Int
+				Title          githubv4.String
+				State          githubv4.String
+				IsDraft        githubv4.Boolean
+				URL            githubv4.URI
+				Mergeable      githubv4.String
+				ReviewDecision *githubv4.String
+				Author         struct {
+					Login githubv4.String
+				}
+				HeadRefName githubv4.String
+				HeadRefOid  githubv4.String
+				BaseRefName githubv4.String
+				BaseRefOid  githubv4.String
+				Additions   githubv4.Int
+				Deletions   githubv4.Int
+				Labels      struct {
+					Nodes []struct {
+						Name githubv4.String
+					}
+				} `graphql:"labels(first: 50)"`
+				Assignees struct {
+					Nodes []struct {
+						Login githubv4.String
+					}
+				} `graphql:"assignees(first: 20)"`
+				ReviewRequests struct {
+					Nodes []struct {
+						RequestedReviewer struct {
+							User struct {
+								Login githubv4.String
+							} `graphql:"... on User"`
+							Team struct {
+								Slug githubv4.String
+							} `graphql:"... on Team"`
+						}
+					}
+				} `graphql:"reviewRequests(first: 50)"`
+				LatestReviews struct {
+					Nodes []struct {
+						State       githubv4.String
+						SubmittedAt *githubv4.DateTime
+						Author      struct {
+							Login githubv4.String
+						}
+						Commit struct {
+							OID githubv4.String `graphql:"oid"`
+						}
+					}
+				} `graphql:"latestReviews(first: 50)"`
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved githubv4.Boolean
+						IsOutdated githubv4.Boolean
+						Comments   struct {
+							Nodes []struct {
+								State githubv4.String
+							}
+						} `graphql:"comments(first: 1)"`
+					}
+					PageInfo struct {
+						HasNextPage githubv4.Boolean
+					}
+				} `graphql:"reviewThreads(first: 100)"`
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State    githubv4.String
+								Contexts struct {
+									Nodes []struct {
+										Typename      githubv4.String `graphql:"__typename"`
+										CheckRun      checkRun        `graphql:"... on CheckRun"`
+										StatusContext statusContext   `graphql:"... on StatusContext"`
+									}
+								} `graphql:"contexts(first: 100)"`
+							}
+						}
+					}
+				} `graphql:"commits(last: 1)"`
+				Files struct {
+					TotalCount githubv4.Int
+					Nodes      []struct {
+						Path       githubv4.String
+						ChangeType githubv4.String
+						Additions  githubv4.Int
+						Deletions  githubv4.Int
+					}
+				} `graphql:"files(first: 100)"`
+			} `graphql:"pullRequest(number: $prNum)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	vars := map[string]any{
+		"owner": githubv4.String(owner),
+		"name":  githubv4.String(repo),
+		"prNum": githubv4.Int(pullNumber),
+	}
+	if err := client.Query(ctx, &query, vars); err != nil {
+		return nil, err
+	}
+
+	pr := query.Repository.PullRequest
+	headSHA := string(pr.HeadRefOid)
+	overview := &pullRequestOverview{
+		Number:    int(pr.Number),
+		Title:     string(pr.Title),
+		State:     string(pr.State),
+		Draft:     bool(pr.IsDraft),
+		Author:    string(pr.Author.Login),
+		Head:      branchDTO{Ref: string(pr.HeadRefName), SHA: headSHA},
+		Base:      branchDTO{Ref: string(pr.BaseRefName), SHA: string(pr.BaseRefOid)},
+		URL:       pr.URL.String(),
+		Mergeable: string(pr.Mergeable),
+		Reviews:   []overviewReview{},
+		Files: overviewFiles{
+			Count:     int(pr.Files.TotalCount),
+			Additions: int(pr.Additions),
+			Deletions: int(pr.Deletions),
+			List:      []overviewFile{},
+		},
+	}
+	if pr.ReviewDecision != nil {
+		overview.ReviewDecision = string(*pr.ReviewDecision)
+	}
+
+	for _, label := range pr.Labels.Nodes {
+		overview.Labels = append(overview.Labels, string(label.Name))
+	}
+	for _, assignee := range pr.Assignees.Nodes {
+		overview.Assignees = append(overview.Assignees, string(assignee.Login))
+	}
+	for _, request := range pr.ReviewRequests.Nodes {
+		if login := request.RequestedReviewer.User.Login; login != "" {
+			overview.RequestedReviewers = append(overview.RequestedReviewers, string(login))
+		} else if slug := request.RequestedReviewer.Team.Slug; slug != "" {
+			overview.RequestedReviewers = append(overview.RequestedReviewers, "team:"+string(slug))
+		}
+	}
+
+	for _, review := range pr.LatestReviews.Nodes {
+		// The viewer's pending review is not a review others can see yet
+		if review.State == "PENDING" {
+			continue
+		}
+		entry := overviewReview{
+			Author: string(review.Author.Login),
+			State:  string(review.State),
+			Stale:  review.Commit.OID != "" && string(review.Commit.OID) != headSHA,
+		}
+		if review.SubmittedAt != nil {
+			entry.SubmittedAt = review.SubmittedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		overview.Reviews = append(overview.Reviews, entry)
+	}
+
+	if pr.ReviewThreads.PageInfo.HasNextPage {
+		threads, err := listReviewThreads(ctx, client, owner, repo, pullNumber)
+		if err != nil {
+			return nil, err
+		}
+		overview.Threads = summarizeThreads(threads)
+	} else {
+		threads := make([]reviewThread, 0, len(pr.ReviewThreads.Nodes))
+		for _, node := range pr.ReviewThreads.Nodes {
+			thread := reviewThread{IsResolved: bool(node.IsResolved), IsOutdated: bool(node.IsOutdated)}
+			if len(node.Comments.Nodes) > 0 {
+				thread.CommentState = string(node.Comments.Nodes[0].State)
+			}
+			threads = append(threads, thread)
+		}
+		overview.Threads = summarizeThreads(threads)
+	}
+
+	var rollupState string
+	var checks []overviewCheck
+	if len(pr.Commits.Nodes) > 0 && pr.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+		rollup := pr.Commits.Nodes[0].Commit.StatusCheckRollup
+		rollupState = string(rollup.State)
+		for _, node := range rollup.Contexts.Nodes {
+			switch node.Typename {
+			case "CheckRun":
+				conclusion := ""
+				if node.CheckRun.Conclusion != nil {
+					conclusion = string(*node.CheckRun.Conclusion)
+				}
+				check := overviewCheck{
+					Name:    string(node.CheckRun.Name),
+					Outcome: checkRunOutcome(string(node.CheckRun.Status), conclusion),
+				}
+				if node.CheckRun.DetailsURL != nil {
+					check.URL = node.CheckRun.DetailsURL.String()
+				}
+				checks = append(checks, check)
+			case "StatusContext":
+				check := overviewCheck{
+					Name:    string(node.StatusContext.Context),
+					Outcome: statusContextOutcome(string(node.StatusContext.State)),
+				}
+				if node.StatusContext.TargetURL != nil {
+					check.URL = node.StatusContext.TargetURL.String()
+				}
+				checks = append(checks, check)
+			}
+		}
+	}
+	overview.Checks = summarizeChecks(rollupState, checks)
+
+	for _, file := range pr.Files.Nodes {
+		overview.Files.List = append(overview.Files.List, overviewFile{
+			Path:       string(file.Path),
+			ChangeType: string(file.ChangeType),
+			Additions:  int(file.Additions),
+			Deletions:  int(file.Deletions),
+		})
+	}
+
+	return overview, nil
+}
@@ -0,0 +1,90 @@
+package qoder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckRunOutcome(t *testing.T) {
+	testCases := []struct {
+		status     string
+		conclusion string
+		expected   string
+	}{
+		{status: "QUEUED", expected: checkPending},
+		{status: "IN_PROGRESS", expected: checkPending},
+		{status: "COMPLETED", conclusion: "SUCCESS", expected: checkPassed},
+		{status: "COMPLETED", conclusion: "NEUTRAL", expected: checkPassed},
+		{status: "COMPLETED", conclusion: "SKIPPED", expected: checkSkipped},
+		{status: "COMPLETED", conclusion: "FAILURE", expected: checkFailed},
+		{status: "COMPLETED", conclusion: "TIMED_OUT", expected: checkFailed},
+		{status: "COMPLETED", conclusion: "CANCELLED", expected: checkFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.status+"/"+tc.conclusion, func(t *testing.T) {
+			if outcome := checkRunOutcome(tc.status, tc.conclusion); outcome != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, outcome)
+			}
+		})
+	}
+}
+
+func TestStatusContextOutcome(t *testing.T) {
+	testCases := map[string]string{
+		"SUCCESS":  checkPassed,
+		"PENDING":  checkPending,
+		"EXPECTED": checkPending,
+		"FAILURE":  checkFailed,
+		"ERROR":    checkFailed,
+	}
+
+	for state, expected := range testCases {
+		if outcome := statusContextOutcome(state); outcome != expected {
+			t.Errorf("%s: expected %q, got %q", state, expected, outcome)
+		}
+	}
+}
+
+func TestSummarizeChecks(t *testing.T) {
+	checks := []overviewCheck{
+		{Name: "build", Outcome: checkPassed},
+		{Name: "lint", Outcome: checkFailed, URL: "https://example.com/lint"},
+		{Name: "e2e", Outcome: checkPending},
+		{Name: "docs", Outcome: checkSkipped},
+		{Name: "unit", Outcome: checkPassed},
+	}
+
+	expected := overviewChecks{
+		State:   "FAILURE",
+		Total:   5,
+		Passed:  2,
+		Failed:  1,
+		Pending: 1,
+		Skipped: 1,
+		Failing: []overviewCheck{{Name: "lint", Outcome: checkFailed, URL: "https://example.com/lint"}},
+		Running: []overviewCheck{{Name: "e2e", Outcome: checkPending}},
+	}
+	if summary := summarizeChecks("FAILURE", checks); !reflect.DeepEqual(summary, expected) {
+		t.Errorf("expected %+v, got %+v", expected, summary)
+	}
+
+	if summary := summarizeChecks("", nil); !reflect.DeepEqual(summary, overviewChecks{}) {
+		t.Errorf("expected an empty summary, got %+v", summary)
+	}
+}
+
+func TestSummarizeThreads(t *testing.T) {
+	threads := []reviewThread{
+		{IsResolved: true},
+		{IsResolved: false},
+		{IsResolved: false, IsOutdated: true},
+		{IsResolved: true, IsOutdated: true},
+		{IsResolved: false, CommentState: "PENDING"},
+	}
+
+	expected := overviewThreads{Total: 4, Unresolved: 2, UnresolvedOutdated: 1}
+	if summary := summarizeThreads(threads); summary != expected {
+		t.Errorf("expected %+v, got %+v", expected, summary)
+	}
+}
@@ -0,0 +1,253 @@
+package qoder
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/google/go-github/v73/github"
+)
+
+var (
+	// pushFileActions lists what push_files can do with a file
+	pushFileActions = []string{"upsert", "delete", "rename"}
+
+	// pushFileModes lists the git modes a pushed file can have: regular, executable and symlink
+	pushFileModes = []string{"100644", "100755", "120000"}
+)
+
+// defaultFileMode is the mode of new files pushed without one
+const defaultFileMode = "100644"
+
+// pushFile is a file entry of push_files
+type pushFile struct {
+	Path         string  `mapstructure:"path"`
+	Action       string  `mapstructure:"action"`
+	Content      *string `mapstructure:"content"`
+	PreviousPath string  `mapstructure:"previous_path"`
+	Mode         string  `mapstructure:"mode"`
+	Encoding     string  `mapstructure:"encoding"`
+
+	blobSHA string // Blob created for base64 content
+}
+
+// validate fills in the defaults of a file entry and checks it is complete
+func (f *pushFile) validate() error {
+	if f.Path == "" {
+		return fmt.Errorf("each file must have a path")
+	}
+	if f.Action == "" {
+		f.Action = "upsert"
+	}
+	if !slices.Contains(pushFileActions, f.Action) {
+		return fmt.Errorf("invalid action %q for %s, must be one of: upsert, delete, rename", f.Action, f.Path)
+	}
+	if f.Mode != "" && !slices.Contains(pushFileModes, f.Mode) {
+		return fmt.Errorf("invalid mode %q for %s, must be one of: 100644, 100755, 120000", f.Mode, f.Path)
+	}
+	if f.Encoding == "" {
+		f.Encoding = "utf-8"
+	}
+
+	switch f.Action {
+	case "upsert":
+		if f.Content == nil {
+			return fmt.Errorf("file %s must have content", f.Path)
+		}
+	case "rename":
+		if f.PreviousPath == "" || f.PreviousPath == f.Path {
+			return fmt.Errorf("renaming %s needs a different previous_path", f.Path)
+		}
+	}
+
+	if f.Content != nil {
+		switch f.Encoding {
+		case "utf-8":
+		case "base64":
+			if _, err := base64.StdEncoding.DecodeString(*f.Content); err != nil {
+				return fmt.Errorf("content of %s is not valid base64: %v", f.Path, err)
+			}
+		default:
+			return fmt.Errorf("invalid encoding %q for %s, must be utf-8 or base64", f.Encoding, f.Path)
+		}
+	}
+	return nil
+}
+
+// needsBaseTree reports whether building the tree entries of files needs the branch's existing files
+// Besides deletes and renames, files pushed without a mode keep the mode they already have
+func needsBaseTree(files []pushFile) bool {
+	for _, f := range files {
+		if f.Action != "upsert" || f.Mode == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pushFilePaths lists the paths of files, previous paths of renames included
+func pushFilePaths(files []pushFile) []string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		if f.PreviousPath != "" {
+			paths = append(paths, f.PreviousPath)
+		}
+	}
+	return paths
+}
+
+// lookupTreeEntries finds the entries of paths in the tree rootSHA, by path
+// Only the directories on the way to each path are listed, one level at a time, so the lookup grows with
+// the number of paths rather than the size of the repository. complete is false when GitHub truncated a
+// listing, so a missing path may still exist.
+func lookupTreeEntries(ctx context.Context, client *github.Client, owner, repo, rootSHA string, paths []string) (map[string]*github.TreeEntry, bool, error) {
+	existing := map[string]*github.TreeEntry{}
+	complete := true
+
+	// Directories already listed, and the tree of each directory found so far, by path
+	listed := map[string]bool{}
+	treeSHAs := map[string]string{".": rootSHA}
+
+	var listDir func(dir string) error
+	listDir = func(dir string) error {
+		if listed[dir] {
+			return nil
+		}
+		listed[dir] = true
+
+		// The parent's listing has the tree of dir
+		if dir != "." {
+			if err := listDir(path.Dir(dir)); err != nil {
+				return err
+			}
+		}
+		sha, ok := treeSHAs[dir]
+		if !ok {
+			// Not on the branch, nor anything under it
+			return nil
+		}
+
+		tree, _, err := client.Git.GetTree(ctx, owner, repo, sha, false)
+		if err != nil {
+			return fmt.Errorf("failed to get tree of %s: %w", dir, err)
+		}
+		if tree.GetTruncated() {
+			complete = false
+		}
+		for _, entry := range tree.Entries {
+			full := entry.GetPath()
+			if dir != "." {
+				full = dir + "/" + full
+			}
+			entry.Path = github.Ptr(full)
+			existing[full] = entry
+			if entry.GetType() == "tree" {
+				treeSHAs[full] = entry.GetSHA()
+			}
+		}
+		return nil
+	}
+
+	for _, p := range paths {
+		if err := listDir(path.Dir(p)); err != nil {
+			return nil, false, err
+		}
+	}
+	return existing, complete, nil
+}
+
+// pushTreeEntries builds the tree entries that apply files to the branch's existing blobs, by path
+// existing is nil when it was not fetched; complete is false when GitHub truncated the listing, so a
+// missing path may still exist.
+func pushTreeEntries(files []pushFile, existing map[string]*github.TreeEntry, complete bool) ([]*github.TreeEntry, error) {
+	seen := map[string]bool{}
+	claim := func(path string) error {
+		if seen[path] {
+			return fmt.Errorf("%s appears more than once in files", path)
+		}
+		seen[path] = true
+		return nil
+	}
+
+	var entries []*github.TreeEntry
+	for _, f := range files {
+		if err := claim(f.Path); err != nil {
+			return nil, err
+		}
+		current := existing[f.Path]
+		if current != nil && current.GetType() != "blob" {
+			return nil, fmt.Errorf("%s is a directory on the branch", f.Path)
+		}
+
+		switch f.Action {
+		case "delete":
+			if current == nil && complete {
+				return nil, fmt.Errorf("cannot delete %s: not found on the branch", f.Path)
+			}
+			entries = append(entries, deletedTreeEntry(f.Path, current))
+
+		case "rename":
+			if err := claim(f.PreviousPath); err != nil {
+				return nil, err
+			}
+			previous := existing[f.PreviousPath]
+			// Without new content the blob to move must be known
+			if (previous == nil || previous.GetType() != "blob") && (f.Content == nil || complete) {
+				return nil, fmt.Errorf("cannot rename %s: not found on the branch", f.PreviousPath)
+			}
+			entries = append(entries, deletedTreeEntry(f.PreviousPath, previous))
+
+			entry := fileTreeEntry(f, previous)
+			if f.Content == nil {
+				// Only moved, keep the blob
+				entry.SHA = previous.SHA
+			}
+			entries = append(entries, entry)
+
+		default:
+			entries = append(entries, fileTreeEntry(f, current))
+		}
+	}
+	return entries, nil
+}
+
+// fileTreeEntry builds the tree entry of a file's new content
+// Without a mode in the request, the file keeps the mode of current, if any
+func fileTreeEntry(f pushFile, current *github.TreeEntry) *github.TreeEntry {
+	mode := f.Mode
+	if mode == "" && current != nil {
+		mode = current.GetMode()
+	}
+	if mode == "" {
+		mode = defaultFileMode
+	}
+
+	entry := &github.TreeEntry{
+		Path: github.Ptr(f.Path),
+		Mode: github.Ptr(mode),
+		Type: github.Ptr("blob"),
+	}
+	switch {
+	case f.blobSHA != "":
+		entry.SHA = github.Ptr(f.blobSHA)
+	case f.Content != nil:
+		entry.Content = github.Ptr(*f.Content)
+	}
+	return entry
+}
+
+// deletedTreeEntry builds the tree entry removing a file: one without SHA or content
+func deletedTreeEntry(path string, current *github.TreeEntry) *github.TreeEntry {
+	mode := defaultFileMode
+	if current != nil && current.GetMode() != "" {
+		mode = current.GetMode()
+	}
+	return &github.TreeEntry{
+		Path: github.Ptr(path),
+		Mode: github.Ptr(mode),
+		Type: github.Ptr("blob"),
+	}
+}
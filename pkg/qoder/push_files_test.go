@@ -0,0 +1,216 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPushFileValidate(t *testing.T) {
+	content := "hello"
+	testCases := []struct {
+		name        string
+		file        pushFile
+		expectError bool
+	}{
+		{name: "upsert by default", file: pushFile{Path: "a.txt", Content: &content}},
+		{name: "upsert without content", file: pushFile{Path: "a.txt"}, expectError: true},
+		{name: "delete", file: pushFile{Path: "a.txt", Action: "delete"}},
+		{name: "rename", file: pushFile{Path: "b.txt", Action: "rename", PreviousPath: "a.txt"}},
+		{name: "rename without previous path", file: pushFile{Path: "b.txt", Action: "rename"}, expectError: true},
+		{name: "rename to itself", file: pushFile{Path: "a.txt", Action: "rename", PreviousPath: "a.txt"}, expectError: true},
+		{name: "executable", file: pushFile{Path: "run.sh", Content: &content, Mode: "100755"}},
+		{name: "invalid mode", file: pushFile{Path: "a.txt", Content: &content, Mode: "040000"}, expectError: true},
+		{name: "invalid action", file: pushFile{Path: "a.txt", Action: "move"}, expectError: true},
+		{name: "valid base64", file: pushFile{Path: "a.bin", Content: github.Ptr("aGVsbG8="), Encoding: "base64"}},
+		{name: "invalid base64", file: pushFile{Path: "a.bin", Content: github.Ptr("not base64!"), Encoding: "base64"}, expectError: true},
+		{name: "invalid encoding", file: pushFile{Path: "a.txt", Content: &content, Encoding: "latin1"}, expectError: true},
+		{name: "missing path", file: pushFile{Content: &content}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.file.validate()
+			if tc.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNeedsBaseTree(t *testing.T) {
+	content := "x"
+	if needsBaseTree([]pushFile{{Path: "a", Action: "upsert", Content: &content, Mode: "100644"}}) {
+		t.Error("expected no base tree for upserts with a mode")
+	}
+	if !needsBaseTree([]pushFile{{Path: "a", Action: "upsert", Content: &content}}) {
+		t.Error("expected the base tree for upserts without a mode")
+	}
+	if !needsBaseTree([]pushFile{{Path: "a", Action: "delete", Mode: "100644"}}) {
+		t.Error("expected the base tree for deletes")
+	}
+}
+
+func TestPushTreeEntries(t *testing.T) {
+	existing := map[string]*github.TreeEntry{
+		"run.sh":   {Path: github.Ptr("run.sh"), Mode: github.Ptr("100755"), Type: github.Ptr("blob"), SHA: github.Ptr("sha-run")},
+		"old.txt":  {Path: github.Ptr("old.txt"), Mode: github.Ptr("100644"), Type: github.Ptr("blob"), SHA: github.Ptr("sha-old")},
+		"gone.txt": {Path: github.Ptr("gone.txt"), Mode: github.Ptr("100644"), Type: github.Ptr("blob"), SHA: github.Ptr("sha-gone")},
+		"pkg":      {Path: github.Ptr("pkg"), Mode: github.Ptr("040000"), Type: github.Ptr("tree"), SHA: github.Ptr("sha-pkg")},
+	}
+	script, text := "#!/bin/sh\necho hi\n", "new"
+
+	t.Run("applies every action", func(t *testing.T) {
+		files := []pushFile{
+			{Path: "run.sh", Action: "upsert", Content: &script},
+			{Path: "new.txt", Action: "upsert", Content: &text},
+			{Path: "image.png", Action: "upsert", Content: github.Ptr("iVBORw=="), Encoding: "base64", blobSHA: "sha-png"},
+			{Path: "gone.txt", Action: "delete"},
+			{Path: "moved.txt", Action: "rename", PreviousPath: "old.txt"},
+		}
+		entries, err := pushTreeEntries(files, existing, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, _ := json.Marshal(entries)
+		expected := `[` +
+			`{"path":"run.sh","mode":"100755","type":"blob","content":"#!/bin/sh\necho hi\n"},` +
+			`{"path":"new.txt","mode":"100644","type":"blob","content":"new"},` +
+			`{"sha":"sha-png","path":"image.png","mode":"100644","type":"blob"},` +
+			`{"sha":null,"path":"gone.txt","mode":"100644","type":"blob"},` +
+			`{"sha":null,"path":"old.txt","mode":"100644","type":"blob"},` +
+			`{"sha":"sha-old","path":"moved.txt","mode":"100644","type":"blob"}` +
+			`]`
+		if string(data) != expected {
+			t.Errorf("expected %s, got %s", expected, data)
+		}
+	})
+
+	t.Run("explicit mode wins", func(t *testing.T) {
+		entries, err := pushTreeEntries([]pushFile{{Path: "run.sh", Action: "upsert", Content: &script, Mode: "100644"}}, existing, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if entries[0].GetMode() != "100644" {
+			t.Errorf("expected mode 100644, got %s", entries[0].GetMode())
+		}
+	})
+
+	errorCases := []struct {
+		name     string
+		files    []pushFile
+		complete bool
+	}{
+		{name: "delete missing file", files: []pushFile{{Path: "missing.txt", Action: "delete"}}, complete: true},
+		{name: "rename missing file", files: []pushFile{{Path: "b.txt", Action: "rename", PreviousPath: "missing.txt"}}},
+		{name: "directory", files: []pushFile{{Path: "pkg", Action: "delete"}}, complete: true},
+		{name: "same path twice", files: []pushFile{{Path: "new.txt", Action: "upsert", Content: &text}, {Path: "new.txt", Action: "delete"}}},
+		{name: "renamed file also changed", files: []pushFile{{Path: "moved.txt", Action: "rename", PreviousPath: "old.txt"}, {Path: "old.txt", Action: "upsert", Content: &text}}},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := pushTreeEntries(tc.files, existing, tc.complete); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("truncated tree allows unknown deletes", func(t *testing.T) {
+		if _, err := pushTreeEntries([]pushFile{{Path: "deep/missing.txt", Action: "delete"}}, existing, false); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestPushFilesKeepsExistingMode(t *testing.T) {
+	var treeRequests []string
+	var created struct {
+		BaseTree string              `json:"base_tree"`
+		Tree     []*github.TreeEntry `json:"tree"`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref": "refs/heads/main", "object": {"sha": "base-commit"}}`)
+	})
+	mux.HandleFunc("GET /repos/owner/repo/git/commits/base-commit", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha": "base-commit", "tree": {"sha": "root-tree"}}`)
+	})
+	mux.HandleFunc("GET /repos/owner/repo/git/trees/{sha}", func(w http.ResponseWriter, r *http.Request) {
+		treeRequests = append(treeRequests, r.URL.RequestURI())
+		switch r.PathValue("sha") {
+		case "root-tree":
+			fmt.Fprint(w, `{"sha": "root-tree", "tree": [
+				{"path": "README.md", "mode": "100644", "type": "blob", "sha": "sha-readme"},
+				{"path": "scripts", "mode": "040000", "type": "tree", "sha": "scripts-tree"}
+			]}`)
+		case "scripts-tree":
+			fmt.Fprint(w, `{"sha": "scripts-tree", "tree": [
+				{"path": "build.sh", "mode": "100755", "type": "blob", "sha": "sha-build"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("POST /repos/owner/repo/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+			t.Errorf("failed to decode tree: %v", err)
+		}
+		fmt.Fprint(w, `{"sha": "new-tree"}`)
+	})
+	mux.HandleFunc("POST /repos/owner/repo/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha": "new-commit"}`)
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/git/refs/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref": "refs/heads/main", "object": {"sha": "new-commit"}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	getClient := func(ctx context.Context) (*github.Client, error) { return client, nil }
+
+	_, handler := PushFiles(getClient, "owner", "repo")
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"branch":  "main",
+		"message": "Update build script",
+		"files": []interface{}{
+			map[string]interface{}{"path": "scripts/build.sh", "content": "#!/bin/sh\nmake\n"},
+			map[string]interface{}{"path": "scripts/new.sh", "content": "#!/bin/sh\n"},
+		},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+
+	// Only the directories of the pushed files are listed, never the whole tree
+	expectedRequests := []string{"/repos/owner/repo/git/trees/root-tree", "/repos/owner/repo/git/trees/scripts-tree"}
+	if fmt.Sprint(treeRequests) != fmt.Sprint(expectedRequests) {
+		t.Errorf("expected tree requests %v, got %v", expectedRequests, treeRequests)
+	}
+
+	if created.BaseTree != "root-tree" || len(created.Tree) != 2 {
+		t.Fatalf("unexpected tree %+v", created)
+	}
+	modes := map[string]string{}
+	for _, entry := range created.Tree {
+		modes[entry.GetPath()] = entry.GetMode()
+	}
+	if modes["scripts/build.sh"] != "100755" || modes["scripts/new.sh"] != "100644" {
+		t.Errorf("expected build.sh to keep 100755 and new.sh to get 100644, got %v", modes)
+	}
+}
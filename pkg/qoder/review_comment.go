@@ -0,0 +1,198 @@
+package qoder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// reviewComment is a comment to add to a pending review as a new thread
+type reviewComment struct {
+	Path        string
+	Body        string // Comment text, without badge, metadata or footer
+	SubjectType string // LINE or FILE
+	Line        *int32
+	Side        *string
+	StartLine   *int32
+	StartSide   *string
+	Meta        commentMeta
+}
+
+// pendingReviewTarget is the pending review comments are added to
+type pendingReviewTarget struct {
+	ID         githubv4.ID
+	CommitOID  string
+	PullNumber int
+}
+
+// duplicateCheck compares a new comment with the existing review threads of its author
+type duplicateCheck struct {
+	Author    string
+	Threads   []reviewThread
+	Action    string // skip or update
+	Threshold float64
+}
+
+// addedReviewComment is the outcome of adding a comment to a pending review
+type addedReviewComment struct {
+	Action     string // created, skipped or updated
+	ThreadID   string
+	CommentID  string
+	CommentURL string
+	Path       string
+	Line       int
+	Similarity float64 // Similarity to the existing comment, for skipped and updated comments
+	Body       string  // Full body as posted, with badge, metadata and footer
+}
+
+// addReviewComment adds a comment to a pending review as a new thread, with the comment metadata and the
+// footer of toolName. If dedupe is set and a similar comment of the same author is already on the same
+// lines, it is skipped or updated instead. Returns an error if GitHub rejects the thread.
+func addReviewComment(ctx context.Context, client *githubv4.Client, footer *Footer, toolName, owner, repo string, review pendingReviewTarget, comment reviewComment, dedupe *duplicateCheck) (*addedReviewComment, error) {
+	var line, startLine int
+	side := "RIGHT"
+	if comment.Line != nil {
+		line = int(*comment.Line)
+	}
+	if comment.StartLine != nil {
+		startLine = int(*comment.StartLine)
+	}
+	if comment.Side != nil {
+		side = *comment.Side
+	}
+
+	// Create QoderFixContext for the footer link
+	fixContext := QoderFixContext{
+		Owner:      owner,
+		Repo:       repo,
+		PullNumber: review.PullNumber,
+		CommitID:   review.CommitOID,
+		Path:       comment.Path,
+		Line:       line,
+		StartLine:  startLine,
+		Body:       comment.Body,
+	}
+	if comment.Side != nil {
+		fixContext.Side = *comment.Side
+	}
+	if comment.StartSide != nil {
+		fixContext.StartSide = *comment.StartSide
+	}
+
+	footerData := footer.Data(toolName, review.PullNumber, review.CommitOID)
+	footerData.FixURL = footer.FixLink(fixContext)
+	fullBody := footer.Append(withCommentMeta(comment.Body, comment.Meta), footerData)
+
+	// Look for a similar comment already posted by the author on the same lines
+	if dedupe != nil {
+		if duplicate, similarity, ok := findDuplicateThread(dedupe.Threads, dedupe.Author, comment.Path, side, startLine, line, fullBody, dedupe.Threshold); ok {
+			result := &addedReviewComment{
+				Action:     "skipped",
+				ThreadID:   fmt.Sprintf("%v", duplicate.ID),
+				CommentID:  fmt.Sprintf("%v", duplicate.CommentID),
+				CommentURL: duplicate.CommentURL,
+				Path:       duplicate.Path,
+				Line:       duplicate.Line,
+				Similarity: similarity,
+				Body:       fullBody,
+			}
+
+			if dedupe.Action == "update" {
+				var updateCommentMutation struct {
+					UpdatePullRequestReviewComment struct {
+						PullRequestReviewComment struct {
+							ID githubv4.ID
+						}
+					} `graphql:"updatePullRequestReviewComment(input: $input)"`
+				}
+				if err := client.Mutate(ctx, &updateCommentMutation, githubv4.UpdatePullRequestReviewCommentInput{
+					PullRequestReviewCommentID: duplicate.CommentID,
+					Body:                       githubv4.String(fullBody),
+				}, nil); err != nil {
+					return nil, fmt.Errorf("failed to update existing comment: %w", err)
+				}
+				result.Action = "updated"
+			}
+			return result, nil
+		}
+	}
+
+	// Then we can create a new review thread comment on the review.
+	var addPullRequestReviewThreadMutation struct {
+		AddPullRequestReviewThread struct {
+			Thread struct {
+				ID       githubv4.ID
+				Comments struct {
+					Nodes []struct {
+						ID           githubv4.ID
+						URL          githubv4.URI
+						Body         githubv4.String
+						Path         githubv4.String
+						Line         *githubv4.Int
+						OriginalLine *githubv4.Int
+					}
+				} `graphql:"comments(first: 1)"`
+			}
+		} `graphql:"addPullRequestReviewThread(input: $input)"`
+	}
+
+	// For single-line LINE comments, GitHub requires both startLine and line to be set
+	// If startLine is not provided but line is, set startLine = line
+	effectiveStartLine := comment.StartLine
+	effectiveStartSide := comment.StartSide
+	if comment.SubjectType == "LINE" && comment.Line != nil && comment.StartLine == nil {
+		effectiveStartLine = comment.Line
+		effectiveStartSide = comment.Side
+	}
+
+	if err := client.Mutate(
+		ctx,
+		&addPullRequestReviewThreadMutation,
+		githubv4.AddPullRequestReviewThreadInput{
+			Path:                githubv4.String(comment.Path),
+			Body:                githubv4.String(fullBody),
+			SubjectType:         newGQLStringlikePtr[githubv4.PullRequestReviewThreadSubjectType](&comment.SubjectType),
+			Line:                newGQLIntPtr(comment.Line),
+			Side:                newGQLStringlikePtr[githubv4.DiffSide](comment.Side),
+			StartLine:           newGQLIntPtr(effectiveStartLine),
+			StartSide:           newGQLStringlikePtr[githubv4.DiffSide](effectiveStartSide),
+			PullRequestReviewID: &review.ID,
+		},
+		nil,
+	); err != nil {
+		return nil, err
+	}
+
+	thread := addPullRequestReviewThreadMutation.AddPullRequestReviewThread.Thread
+
+	// Verify mutation succeeded
+	if thread.ID == "" {
+		var line int32
+		var side string
+		if comment.Line != nil {
+			line = *comment.Line
+		}
+		if comment.Side != nil {
+			side = *comment.Side
+		}
+		return nil, fmt.Errorf("failed to create comment thread: position (path=%s, line=%d, side=%s) may not be valid in the PR diff",
+			comment.Path, line, side)
+	}
+
+	result := &addedReviewComment{
+		Action:   "created",
+		ThreadID: fmt.Sprintf("%v", thread.ID),
+		Path:     comment.Path,
+		Body:     fullBody,
+	}
+	if len(thread.Comments.Nodes) > 0 {
+		created := thread.Comments.Nodes[0]
+		result.CommentID = fmt.Sprintf("%v", created.ID)
+		result.CommentURL = created.URL.String()
+		if created.Line != nil {
+			result.Line = int(*created.Line)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package qoder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v73/github"
+)
+
+// defaultDismissMessage is the dismissal message used when none is given, %s is the new review's URL
+const defaultDismissMessage = "Superseded by a newer review: %s"
+
+// reviewDismissal is the outcome of dismissing one previous review
+type reviewDismissal struct {
+	ReviewID int64  `json:"review_id"`
+	URL      string `json:"url"`
+	Error    string `json:"error,omitempty"`
+}
+
+// selectChangeRequests returns the CHANGES_REQUESTED reviews by login, other than the review to keep
+func selectChangeRequests(reviews []*github.PullRequestReview, login string, keepID int64) []*github.PullRequestReview {
+	var selected []*github.PullRequestReview
+	for _, review := range reviews {
+		if review.GetID() == keepID || review.GetState() != "CHANGES_REQUESTED" {
+			continue
+		}
+		if sameLogin(review.GetUser().GetLogin(), login) {
+			selected = append(selected, review)
+		}
+	}
+	return selected
+}
+
+// dismissPreviousChangeRequests dismisses the CHANGES_REQUESTED reviews left by login on a pull request,
+// except the review to keep. Failures are reported per review instead of stopping the others.
+func dismissPreviousChangeRequests(ctx context.Context, client *github.Client, owner, repo string, pullNumber int, login string, keepID int64, message string) ([]reviewDismissal, error) {
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, pullNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PR reviews: %w", err)
+		}
+		reviews = append(reviews, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	dismissals := []reviewDismissal{}
+	for _, review := range selectChangeRequests(reviews, login, keepID) {
+		dismissal := reviewDismissal{ReviewID: review.GetID(), URL: review.GetHTMLURL()}
+		_, _, err := client.PullRequests.DismissReview(ctx, owner, repo, pullNumber, review.GetID(), &github.PullRequestReviewDismissalRequest{
+			Message: github.Ptr(message),
+		})
+		if err != nil {
+			dismissal.Error = err.Error()
+		}
+		dismissals = append(dismissals, dismissal)
+	}
+	return dismissals, nil
+}
@@ -0,0 +1,52 @@
+package qoder
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+func TestSelectChangeRequests(t *testing.T) {
+	review := func(id int64, login, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			ID:    github.Ptr(id),
+			User:  &github.User{Login: github.Ptr(login)},
+			State: github.Ptr(state),
+		}
+	}
+
+	reviews := []*github.PullRequestReview{
+		review(1, "qoder[bot]", "CHANGES_REQUESTED"),
+		review(2, "qoder[bot]", "COMMENTED"),
+		review(3, "octocat", "CHANGES_REQUESTED"),
+		review(4, "qoder[bot]", "DISMISSED"),
+		review(5, "qoder[bot]", "CHANGES_REQUESTED"),
+		review(6, "qoder[bot]", "APPROVED"),
+	}
+
+	testCases := []struct {
+		name     string
+		login    string
+		keepID   int64
+		expected []int64
+	}{
+		{name: "GraphQL login without bot suffix", login: "qoder", keepID: 6, expected: []int64{1, 5}},
+		{name: "keeps the new review", login: "qoder[bot]", keepID: 5, expected: []int64{1}},
+		{name: "other user", login: "octocat", keepID: 0, expected: []int64{3}},
+		{name: "no matches", login: "someone", keepID: 0, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := selectChangeRequests(reviews, tc.login, tc.keepID)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("got %d reviews, expected %d", len(got), len(tc.expected))
+			}
+			for i, r := range got {
+				if r.GetID() != tc.expected[i] {
+					t.Errorf("review %d: got ID %d, expected %d", i, r.GetID(), tc.expected[i])
+				}
+			}
+		})
+	}
+}
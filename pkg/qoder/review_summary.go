@@ -0,0 +1,203 @@
+package qoder
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// reviewSeverities lists the comment severities from most to least severe
+var reviewSeverities = []string{"blocker", "major", "minor", "nit"}
+
+// severityEmoji is the marker shown next to each severity
+var severityEmoji = map[string]string{
+	"blocker": "🔴",
+	"major":   "🟠",
+	"minor":   "🟡",
+	"nit":     "⚪",
+}
+
+// maxSummaryExcerptLength is the maximum number of characters of a comment shown in the summary table
+const maxSummaryExcerptLength = 80
+
+// severityTagPattern matches a severity tag at the start of a comment, e.g. "[major]", "**Nit**:" or "🔴 Blocker"
+var severityTagPattern = regexp.MustCompile(`(?i)^\W*(blocker|major|minor|nit)\b\W*`)
+
+// reviewSummaryComment is a pending review comment as used by the review summary
+type reviewSummaryComment struct {
+	Path string
+	Line int
+	URL  string
+	Body string
+}
+
+// fetchPendingReviewComments gets all comments of a pending review by its GraphQL node ID
+func fetchPendingReviewComments(ctx context.Context, client *githubv4.Client, reviewID githubv4.ID) ([]reviewSummaryComment, error) {
+	var query struct {
+		Node struct {
+			PullRequestReview struct {
+				Comments struct {
+					Nodes []struct {
+						Body         githubv4.String
+						Path         githubv4.String
+						Line         *githubv4.Int
+						OriginalLine *githubv4.Int
+						URL          githubv4.URI
+					}
+					PageInfo struct {
+						HasNextPage githubv4.Boolean
+						EndCursor   githubv4.String
+					}
+				} `graphql:"comments(first: 100, after: $cursor)"`
+			} `graphql:"... on PullRequestReview"`
+		} `graphql:"node(id: $id)"`
+	}
+
+	vars := map[string]any{
+		"id":     reviewID,
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	var comments []reviewSummaryComment
+	for {
+		if err := client.Query(ctx, &query, vars); err != nil {
+			return nil, err
+		}
+
+		for _, node := range query.Node.PullRequestReview.Comments.Nodes {
+			comment := reviewSummaryComment{
+				Path: string(node.Path),
+				URL:  node.URL.String(),
+				Body: string(node.Body),
+			}
+			if node.Line != nil {
+				comment.Line = int(*node.Line)
+			} else if node.OriginalLine != nil {
+				comment.Line = int(*node.OriginalLine)
+			}
+			comments = append(comments, comment)
+		}
+
+		pageInfo := query.Node.PullRequestReview.Comments.PageInfo
+		if !pageInfo.HasNextPage {
+			return comments, nil
+		}
+		vars["cursor"] = githubv4.NewString(pageInfo.EndCursor)
+	}
+}
+
+// commentSeverity returns the severity of a comment, or "" if it has none
+// The hidden metadata of our own comments takes precedence over a tag at the start of the text
+func commentSeverity(body string) string {
+	if meta, ok := parseCommentMeta(body); ok && meta.Severity != "" {
+		return meta.Severity
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if match := severityTagPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			return strings.ToLower(match[1])
+		}
+		return ""
+	}
+	return ""
+}
+
+// commentExcerpt returns the first line of prose of a comment, without its severity tag, for a table cell
+func commentExcerpt(body string) string {
+	for _, line := range strings.Split(stripCommentMeta(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") || strings.HasPrefix(line, "<!--") {
+			continue
+		}
+
+		line = strings.TrimSpace(severityTagPattern.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+
+		if utf8.RuneCountInString(line) > maxSummaryExcerptLength {
+			line = string([]rune(line)[:maxSummaryExcerptLength-1]) + "…"
+		}
+		// Keep the excerpt inside its table cell
+		return strings.ReplaceAll(line, "|", `\|`)
+	}
+	return ""
+}
+
+// buildReviewSummary builds a markdown overview of the comments of a review:
+// counts by severity, the files touched and a table linking to every comment
+func buildReviewSummary(comments []reviewSummaryComment) string {
+	if len(comments) == 0 {
+		return ""
+	}
+
+	counts := map[string]int{}
+	var files []string
+	seenFiles := map[string]bool{}
+	for _, comment := range comments {
+		counts[commentSeverity(comment.Body)]++
+		if !seenFiles[comment.Path] {
+			seenFiles[comment.Path] = true
+			files = append(files, comment.Path)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### 📋 Review summary\n\n")
+
+	commentWord, fileWord := "comments", "files"
+	if len(comments) == 1 {
+		commentWord = "comment"
+	}
+	if len(files) == 1 {
+		fileWord = "file"
+	}
+	fmt.Fprintf(&sb, "**%d** %s on **%d** %s", len(comments), commentWord, len(files), fileWord)
+	for _, severity := range reviewSeverities {
+		if counts[severity] > 0 {
+			fmt.Fprintf(&sb, " · %s %d %s", severityEmoji[severity], counts[severity], severity)
+		}
+	}
+	if counts[""] > 0 {
+		fmt.Fprintf(&sb, " · %d untagged", counts[""])
+	}
+	sb.WriteString("\n\n")
+
+	quotedFiles := make([]string, len(files))
+	for i, file := range files {
+		quotedFiles[i] = "`" + file + "`"
+	}
+	sb.WriteString("Files: " + strings.Join(quotedFiles, ", ") + "\n\n")
+
+	sb.WriteString("| # | Severity | Location | Comment |\n")
+	sb.WriteString("|---|----------|----------|---------|\n")
+	for i, comment := range comments {
+		severity := commentSeverity(comment.Body)
+		severityCell := "—"
+		if severity != "" {
+			severityCell = severityEmoji[severity] + " " + severity
+		}
+		if meta, ok := parseCommentMeta(comment.Body); ok && meta.Category != "" {
+			severityCell += " · `" + meta.Category + "`"
+		}
+
+		location := "`" + comment.Path + "`"
+		if comment.Line > 0 {
+			location = fmt.Sprintf("`%s:%d`", comment.Path, comment.Line)
+		}
+		if comment.URL != "" {
+			location = fmt.Sprintf("[%s](%s)", location, comment.URL)
+		}
+
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s |\n", i+1, severityCell, location, commentExcerpt(comment.Body))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,105 @@
+package qoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentSeverity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "bracket tag", body: "[major] Missing error check", expected: "major"},
+		{name: "bold tag with colon", body: "**Nit**: rename this variable", expected: "nit"},
+		{name: "emoji prefix", body: "🔴 Blocker: nil pointer dereference", expected: "blocker"},
+		{name: "leading blank lines", body: "\n\nminor: typo", expected: "minor"},
+		{name: "no tag", body: "This loop never terminates", expected: ""},
+		{name: "tag not on first line", body: "Consider this\n[major] later", expected: ""},
+		{name: "word prefix is not a tag", body: "Nitpicking here, but", expected: ""},
+		{name: "empty body", body: "", expected: ""},
+		{name: "metadata wins over text", body: "🟠 **Major**\n\nfoo\n\n<!-- qoder-meta: {\"severity\":\"blocker\"} -->", expected: "blocker"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := commentSeverity(tc.body); got != tc.expected {
+				t.Errorf("commentSeverity(%q) = %q, expected %q", tc.body, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommentExcerpt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "strips severity tag", body: "[major] Missing error check\nMore details", expected: "Missing error check"},
+		{name: "skips suggestion fence", body: "```suggestion\nfoo()\n```", expected: "foo()"},
+		{name: "escapes pipes", body: "a | b", expected: `a \| b`},
+		{name: "tag only line", body: "**Nit**:\nrename this", expected: "rename this"},
+		{name: "skips badge", body: "🟠 **Major** · `security`\n\nToken is logged\n\n<!-- qoder-meta: {\"severity\":\"major\",\"category\":\"security\"} -->", expected: "Token is logged"},
+		{name: "truncates long lines", body: strings.Repeat("x", 100), expected: strings.Repeat("x", 79) + "…"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := commentExcerpt(tc.body); got != tc.expected {
+				t.Errorf("commentExcerpt(%q) = %q, expected %q", tc.body, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestBuildReviewSummary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		comments []reviewSummaryComment
+		expected string
+	}{
+		{
+			name:     "no comments",
+			comments: nil,
+			expected: "",
+		},
+		{
+			name: "counts severities and files",
+			comments: []reviewSummaryComment{
+				{Path: "main.go", Line: 12, URL: "https://github.com/o/r/pull/1#discussion_r1", Body: "[blocker] Nil dereference"},
+				{Path: "main.go", Line: 30, URL: "https://github.com/o/r/pull/1#discussion_r2", Body: "nit: typo"},
+				{Path: "util.go", Body: "Consider a helper"},
+			},
+			expected: "### 📋 Review summary\n\n" +
+				"**3** comments on **2** files · 🔴 1 blocker · ⚪ 1 nit · 1 untagged\n\n" +
+				"Files: `main.go`, `util.go`\n\n" +
+				"| # | Severity | Location | Comment |\n" +
+				"|---|----------|----------|---------|\n" +
+				"| 1 | 🔴 blocker | [`main.go:12`](https://github.com/o/r/pull/1#discussion_r1) | Nil dereference |\n" +
+				"| 2 | ⚪ nit | [`main.go:30`](https://github.com/o/r/pull/1#discussion_r2) | typo |\n" +
+				"| 3 | — | `util.go` | Consider a helper |",
+		},
+		{
+			name: "single comment",
+			comments: []reviewSummaryComment{
+				{Path: "a.go", Line: 1, Body: "[minor] Shadowed variable"},
+			},
+			expected: "### 📋 Review summary\n\n" +
+				"**1** comment on **1** file · 🟡 1 minor\n\n" +
+				"Files: `a.go`\n\n" +
+				"| # | Severity | Location | Comment |\n" +
+				"|---|----------|----------|---------|\n" +
+				"| 1 | 🟡 minor | `a.go:1` | Shadowed variable |",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := buildReviewSummary(tc.comments); got != tc.expected {
+				t.Errorf("buildReviewSummary() =\n%s\nexpected:\n%s", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package qoder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultSimilarityThreshold is the similarity above which a new comment is a duplicate of an existing one
+const defaultSimilarityThreshold = 0.8
+
+// reviewThread is a pull request review thread with its first comment
+type reviewThread struct {
+	ID                githubv4.ID
+	Path              string
+	SubjectType       string
+	Line              int // 0 if the thread's lines no longer exist at head
+	StartLine         int
+	OriginalLine      int
+	OriginalStartLine int
+	DiffSide          string
+	IsOutdated        bool
+	IsResolved        bool
+	CommentID         githubv4.ID
+	CommentURL        string
+	CommentState      string // PENDING for comments of an unsubmitted review
+	IsMinimized       bool
+	Body              string
+	Author            string
+}
+
+// listReviewThreads gets all review threads of a pull request, including the viewer's pending ones
+func listReviewThreads(ctx context.Context, client *githubv4.Client, owner, repo string, pullNumber int) ([]reviewThread, error) {
+	var query struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						ID                githubv4.ID
+						Path              githubv4.String
+						SubjectType       githubv4.String
+						Line              *githubv4.Int
+						StartLine         *githubv4.Int
+						OriginalLine      *githubv4.Int
+						OriginalStartLine *githubv4.Int
+						DiffSide          githubv4.String
+						IsOutdated        githubv4.Boolean
+						IsResolved        githubv4.Boolean
+						Comments          struct {
+							Nodes []struct {
+								ID          githubv4.ID
+								URL         githubv4.URI
+								Body        githubv4.String
+								State       githubv4.String
+								IsMinimized githubv4.Boolean
+								Author      struct {
+									Login githubv4.String
+								}
+							}
+						} `graphql:"comments(first: 1)"`
+					}
+					PageInfo struct {
+						HasNextPage githubv4.Boolean
+						EndCursor   githubv4.String
+					}
+				} `graphql:"reviewThreads(first: 100, after: $cursor)"`
+			} `graphql:"pullRequest(number: $prNum)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	vars := map[string]any{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"prNum":  githubv4.Int(pullNumber),
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	intValue := func(i *githubv4.Int) int {
+		if i == nil {
+			return 0
+		}
+		return int(*i)
+	}
+
+	var threads []reviewThread
+	for {
+		if err := client.Query(ctx, &query, vars); err != nil {
+			return nil, err
+		}
+
+		for _, node := range query.Repository.PullRequest.ReviewThreads.Nodes {
+			thread := reviewThread{
+				ID:                node.ID,
+				Path:              string(node.Path),
+				SubjectType:       string(node.SubjectType),
+				Line:              intValue(node.Line),
+				StartLine:         intValue(node.StartLine),
+				OriginalLine:      intValue(node.OriginalLine),
+				OriginalStartLine: intValue(node.OriginalStartLine),
+				DiffSide:          string(node.DiffSide),
+				IsOutdated:        bool(node.IsOutdated),
+				IsResolved:        bool(node.IsResolved),
+			}
+			if len(node.Comments.Nodes) > 0 {
+				comment := node.Comments.Nodes[0]
+				thread.CommentID = comment.ID
+				thread.CommentURL = comment.URL.String()
+				thread.CommentState = string(comment.State)
+				thread.IsMinimized = bool(comment.IsMinimized)
+				thread.Body = string(comment.Body)
+				thread.Author = string(comment.Author.Login)
+			}
+			threads = append(threads, thread)
+		}
+
+		pageInfo := query.Repository.PullRequest.ReviewThreads.PageInfo
+		if !pageInfo.HasNextPage {
+			return threads, nil
+		}
+		vars["cursor"] = githubv4.NewString(pageInfo.EndCursor)
+	}
+}
+
+// newDuplicateCheck lists a pull request's review threads to look for duplicates of author's comments
+// Deduplication is best effort: when the threads cannot be listed it returns nil and comments are posted anyway.
+func newDuplicateCheck(ctx context.Context, client *githubv4.Client, owner, repo string, pullNumber int, author, action string, threshold float64) *duplicateCheck {
+	threads, err := listReviewThreads(ctx, client, owner, repo, pullNumber)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list review threads: %v\n", err)
+		return nil
+	}
+	return &duplicateCheck{
+		Author:    author,
+		Threads:   threads,
+		Action:    action,
+		Threshold: threshold,
+	}
+}
+
+// sameLogin compares GitHub logins, ignoring case and the "[bot]" suffix that only REST adds to app logins
+func sameLogin(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "[bot]"), strings.TrimSuffix(b, "[bot]"))
+}
+
+// overlapsRange reports whether a thread is on the given side and line range at head
+// A zero line means a file-level comment, which only matches file-level threads
+func (t reviewThread) overlapsRange(path, side string, startLine, line int) bool {
+	if t.Path != path {
+		return false
+	}
+	if line == 0 {
+		return t.SubjectType == "FILE"
+	}
+	if t.SubjectType == "FILE" || t.Line == 0 {
+		return false
+	}
+	if side != "" && t.DiffSide != "" && side != t.DiffSide {
+		return false
+	}
+
+	threadStart := t.StartLine
+	if threadStart == 0 {
+		threadStart = t.Line
+	}
+	if startLine == 0 {
+		startLine = line
+	}
+	return threadStart <= line && startLine <= t.Line
+}
+
+// findDuplicateThread finds the thread by author on the same lines whose first comment is most similar to
+// body, if the similarity reaches the threshold
+func findDuplicateThread(threads []reviewThread, author, path, side string, startLine, line int, body string, threshold float64) (reviewThread, float64, bool) {
+	var best reviewThread
+	bestScore := -1.0
+	for _, thread := range threads {
+		if thread.IsResolved || !sameLogin(thread.Author, author) || !thread.overlapsRange(path, side, startLine, line) {
+			continue
+		}
+		if score := commentSimilarity(thread.Body, body); score > bestScore {
+			best, bestScore = thread, score
+		}
+	}
+
+	if bestScore < threshold {
+		return reviewThread{}, 0, false
+	}
+	return best, bestScore, true
+}
+
+// commentText returns the text the author wrote, without our badge, metadata and footer
+func commentText(body string) string {
+	body = stripCommentMeta(stripFooter(body))
+	// Comments posted before the footer marker existed end with a "---" separator and the Qoder footer
+	if idx := strings.LastIndex(body, "\n---\n"); idx != -1 && strings.Contains(body[idx:], "Qoder") {
+		body = body[:idx]
+	}
+	return strings.TrimSpace(body)
+}
+
+// commentSimilarity returns the cosine similarity of the word counts of two comments, from 0 to 1
+func commentSimilarity(a, b string) float64 {
+	countsA := wordCounts(commentText(a))
+	countsB := wordCounts(commentText(b))
+	if len(countsA) == 0 || len(countsB) == 0 {
+		if len(countsA) == 0 && len(countsB) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for word, countA := range countsA {
+		dot += float64(countA * countsB[word])
+		normA += float64(countA * countA)
+	}
+	for _, countB := range countsB {
+		normB += float64(countB * countB)
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// wordCounts counts the lowercase words of a text, splitting on anything but letters, digits and underscores
+func wordCounts(text string) map[string]int {
+	counts := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		counts[word]++
+	}
+	return counts
+}
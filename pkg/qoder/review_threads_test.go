@@ -0,0 +1,106 @@
+package qoder
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCommentSimilarity(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		min  float64
+		max  float64
+	}{
+		{name: "identical", a: "Missing error check on Close", b: "Missing error check on Close", min: 1, max: 1},
+		{name: "case and punctuation", a: "Missing error check on Close.", b: "missing error-check on close", min: 1, max: 1},
+		{name: "footer and metadata ignored", a: "🟠 **Major**\n\nMissing error check\n\n<!-- qoder-meta: {\"severity\":\"major\"} -->\n\n---\n🤖 Generated by [Qoder](https://qoder.com/)", b: "Missing error check", min: 1, max: 1},
+		{name: "reworded", a: "The error returned by Close is not checked", b: "The error returned by Close is ignored and not checked", min: 0.8, max: 0.99},
+		{name: "unrelated", a: "Missing error check", b: "Rename this variable for clarity", min: 0, max: 0},
+		{name: "one empty", a: "", b: "text", min: 0, max: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := commentSimilarity(tc.a, tc.b)
+			if got < tc.min-1e-9 || got > tc.max+1e-9 {
+				t.Errorf("commentSimilarity() = %f, expected between %f and %f", got, tc.min, tc.max)
+			}
+			if reverse := commentSimilarity(tc.b, tc.a); math.Abs(reverse-got) > 1e-9 {
+				t.Errorf("commentSimilarity is not symmetric: %f vs %f", got, reverse)
+			}
+		})
+	}
+}
+
+func TestReviewThreadOverlapsRange(t *testing.T) {
+	lineThread := reviewThread{Path: "main.go", SubjectType: "LINE", StartLine: 10, Line: 15, DiffSide: "RIGHT"}
+	singleLineThread := reviewThread{Path: "main.go", SubjectType: "LINE", Line: 20, DiffSide: "RIGHT"}
+	fileThread := reviewThread{Path: "main.go", SubjectType: "FILE"}
+	outdatedThread := reviewThread{Path: "main.go", SubjectType: "LINE", OriginalLine: 12, DiffSide: "RIGHT", IsOutdated: true}
+
+	testCases := []struct {
+		name      string
+		thread    reviewThread
+		path      string
+		side      string
+		startLine int
+		line      int
+		expected  bool
+	}{
+		{name: "inside range", thread: lineThread, path: "main.go", side: "RIGHT", line: 12, expected: true},
+		{name: "overlapping range", thread: lineThread, path: "main.go", side: "RIGHT", startLine: 14, line: 18, expected: true},
+		{name: "after range", thread: lineThread, path: "main.go", side: "RIGHT", startLine: 16, line: 18, expected: false},
+		{name: "other side", thread: lineThread, path: "main.go", side: "LEFT", line: 12, expected: false},
+		{name: "other file", thread: lineThread, path: "util.go", side: "RIGHT", line: 12, expected: false},
+		{name: "same single line", thread: singleLineThread, path: "main.go", side: "RIGHT", line: 20, expected: true},
+		{name: "file comment matches file thread", thread: fileThread, path: "main.go", expected: true},
+		{name: "line comment does not match file thread", thread: fileThread, path: "main.go", side: "RIGHT", line: 5, expected: false},
+		{name: "outdated thread has no line at head", thread: outdatedThread, path: "main.go", side: "RIGHT", line: 12, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.thread.overlapsRange(tc.path, tc.side, tc.startLine, tc.line); got != tc.expected {
+				t.Errorf("overlapsRange() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateThread(t *testing.T) {
+	threads := []reviewThread{
+		{ID: "T1", Path: "main.go", SubjectType: "LINE", Line: 10, DiffSide: "RIGHT", Author: "qoder", Body: "Rename this variable"},
+		{ID: "T2", Path: "main.go", SubjectType: "LINE", Line: 10, DiffSide: "RIGHT", Author: "qoder", Body: "Missing error check on Close"},
+		{ID: "T3", Path: "main.go", SubjectType: "LINE", Line: 10, DiffSide: "RIGHT", Author: "someone", Body: "Missing error check on Close call"},
+		{ID: "T4", Path: "main.go", SubjectType: "LINE", Line: 10, DiffSide: "RIGHT", Author: "qoder", Body: "Missing error check on Close", IsResolved: true},
+	}
+
+	testCases := []struct {
+		name       string
+		author     string
+		body       string
+		threshold  float64
+		expectedID string
+	}{
+		{name: "most similar thread by author", author: "qoder[bot]", body: "Missing error check on Close()", threshold: 0.8, expectedID: "T2"},
+		{name: "below threshold", author: "qoder", body: "Close may fail", threshold: 0.8, expectedID: ""},
+		{name: "other authors are ignored", author: "someone-else", body: "Missing error check on Close", threshold: 0.8, expectedID: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			thread, _, ok := findDuplicateThread(threads, tc.author, "main.go", "RIGHT", 0, 10, tc.body, tc.threshold)
+			if tc.expectedID == "" {
+				if ok {
+					t.Errorf("expected no duplicate, got %v", thread.ID)
+				}
+				return
+			}
+			if !ok || thread.ID != tc.expectedID {
+				t.Errorf("expected duplicate %s, got %v (found: %v)", tc.expectedID, thread.ID, ok)
+			}
+		})
+	}
+}
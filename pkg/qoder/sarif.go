@@ -0,0 +1,526 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/google/go-github/v73/github"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultSARIFMaxComments bounds the review threads one SARIF document adds
+const defaultSARIFMaxComments = 30
+
+// sarifLevelRank orders SARIF result levels from least to most severe
+var sarifLevelRank = map[string]int{"none": 0, "note": 1, "warning": 2, "error": 3}
+
+// sarifLevelSeverity is the comment severity given to findings of each SARIF level
+var sarifLevelSeverity = map[string]string{
+	"error":   "major",
+	"warning": "minor",
+	"note":    "nit",
+	"none":    "nit",
+}
+
+// sarifMessage is a SARIF message, with {0}-style placeholders filled from its arguments
+type sarifMessage struct {
+	Text      string   `json:"text"`
+	Markdown  string   `json:"markdown"`
+	Arguments []string `json:"arguments"`
+}
+
+// sarifRule is a rule described by the tool that produced a SARIF run
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	HelpURI              string       `json:"helpUri"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+// sarifResult is a single result of a SARIF run
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      struct {
+		ID    string `json:"id"`
+		Index *int   `json:"index"`
+	} `json:"rule"`
+	Kind      string       `json:"kind"`
+	Level     string       `json:"level"`
+	Message   sarifMessage `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+				EndLine   int `json:"endLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+	Suppressions []json.RawMessage `json:"suppressions"`
+}
+
+// sarifLog is the part of a SARIF 2.1.0 document we read
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name  string      `json:"name"`
+				Rules []sarifRule `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []sarifResult `json:"results"`
+	} `json:"runs"`
+}
+
+// sarifFinding is a SARIF result flattened with its rule and first location
+type sarifFinding struct {
+	Tool      string
+	RuleID    string
+	RuleName  string
+	HelpURI   string
+	Level     string // error, warning, note or none
+	Message   string
+	Path      string // As reported, before matching the pull request's files
+	StartLine int    // 0 if the result has no line
+	EndLine   int
+}
+
+// text returns the message text with its placeholders filled in
+func (m sarifMessage) text() string {
+	text := m.Text
+	if text == "" {
+		text = m.Markdown
+	}
+	for i, arg := range m.Arguments {
+		text = strings.ReplaceAll(text, "{"+strconv.Itoa(i)+"}", arg)
+	}
+	return strings.TrimSpace(text)
+}
+
+// parseSARIF reads the findings of a SARIF document
+// Results that passed, do not apply or are suppressed are left out
+func parseSARIF(data []byte) ([]sarifFinding, error) {
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("invalid SARIF document: %w", err)
+	}
+
+	var findings []sarifFinding
+	for _, run := range log.Runs {
+		rules := run.Tool.Driver.Rules
+		rulesByID := make(map[string]sarifRule, len(rules))
+		for _, rule := range rules {
+			rulesByID[rule.ID] = rule
+		}
+
+		for _, result := range run.Results {
+			if result.Kind == "pass" || result.Kind == "notApplicable" || len(result.Suppressions) > 0 {
+				continue
+			}
+
+			ruleID := result.RuleID
+			if ruleID == "" {
+				ruleID = result.Rule.ID
+			}
+			ruleIndex := result.RuleIndex
+			if ruleIndex == nil {
+				ruleIndex = result.Rule.Index
+			}
+			rule, ok := rulesByID[ruleID]
+			if !ok && ruleIndex != nil && *ruleIndex >= 0 && *ruleIndex < len(rules) {
+				rule = rules[*ruleIndex]
+				if ruleID == "" {
+					ruleID = rule.ID
+				}
+			}
+
+			finding := sarifFinding{
+				Tool:     run.Tool.Driver.Name,
+				RuleID:   ruleID,
+				RuleName: rule.Name,
+				HelpURI:  rule.HelpURI,
+				Level:    result.Level,
+				Message:  result.Message.text(),
+			}
+			if finding.Level == "" {
+				finding.Level = rule.DefaultConfiguration.Level
+			}
+			if _, ok := sarifLevelRank[finding.Level]; !ok {
+				finding.Level = "warning"
+			}
+			if finding.Message == "" {
+				finding.Message = rule.ShortDescription.text()
+			}
+
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				finding.Path = location.ArtifactLocation.URI
+				finding.StartLine = location.Region.StartLine
+				finding.EndLine = max(location.Region.EndLine, location.Region.StartLine)
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// normalizeSARIFPath turns an artifact URI into a slash-separated path without scheme or leading "./" and "/"
+func normalizeSARIFPath(uri string) string {
+	uri = strings.TrimPrefix(uri, "file://")
+	if unescaped, err := url.PathUnescape(uri); err == nil {
+		uri = unescaped
+	}
+	uri = strings.ReplaceAll(uri, "\\", "/")
+	for strings.HasPrefix(uri, "./") {
+		uri = uri[2:]
+	}
+	return strings.TrimLeft(uri, "/")
+}
+
+// matchChangedFile finds the changed file a SARIF path refers to: the file itself, or for absolute paths
+// of a checkout, the longest changed file the path ends with
+func matchChangedFile(path string, hunks map[string][]lineRange) (string, bool) {
+	path = normalizeSARIFPath(path)
+	if _, ok := hunks[path]; ok {
+		return path, true
+	}
+
+	match := ""
+	for file := range hunks {
+		if strings.HasSuffix(path, "/"+file) && len(file) > len(match) {
+			match = file
+		}
+	}
+	return match, match != ""
+}
+
+// lineRange is an inclusive range of lines
+type lineRange struct {
+	Start int
+	End   int
+}
+
+// diffHunks returns the lines of the new file each hunk of a patch covers, where review comments can go
+func diffHunks(patch string) []lineRange {
+	var hunks []lineRange
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "@@") {
+			continue
+		}
+		_, _, newStart, newLines, err := parseChunkHeader(line)
+		if err != nil || newLines == 0 {
+			continue
+		}
+		hunks = append(hunks, lineRange{Start: newStart, End: newStart + newLines - 1})
+	}
+	return hunks
+}
+
+// mapToHunk fits the lines of a finding into the first hunk they overlap, as GitHub does not allow
+// comments across hunks. Returns false if no line of the finding is in a hunk.
+func mapToHunk(hunks []lineRange, startLine, endLine int) (int, int, bool) {
+	for _, hunk := range hunks {
+		if startLine <= hunk.End && hunk.Start <= endLine {
+			return max(startLine, hunk.Start), min(endLine, hunk.End), true
+		}
+	}
+	return 0, 0, false
+}
+
+// renderSARIFFinding renders the comment body of a finding, led by its rule ID
+func renderSARIFFinding(finding sarifFinding) string {
+	var sb strings.Builder
+	if finding.RuleID != "" {
+		fmt.Fprintf(&sb, "**`%s`**", finding.RuleID)
+		if finding.RuleName != "" && finding.RuleName != finding.RuleID {
+			fmt.Fprintf(&sb, " %s", finding.RuleName)
+		}
+		if finding.Tool != "" {
+			fmt.Fprintf(&sb, " (%s)", finding.Tool)
+		}
+		sb.WriteString("\n\n")
+	} else if finding.Tool != "" {
+		fmt.Fprintf(&sb, "**%s**\n\n", finding.Tool)
+	}
+
+	sb.WriteString(finding.Message)
+	if finding.HelpURI != "" {
+		fmt.Fprintf(&sb, "\n\n[Rule documentation](%s)", finding.HelpURI)
+	}
+	return sb.String()
+}
+
+// sarifComment is a finding placed on the pull request's diff, and what became of it
+type sarifComment struct {
+	RuleID     string  `json:"rule_id,omitempty"`
+	Level      string  `json:"level"`
+	Path       string  `json:"path"`
+	StartLine  int     `json:"start_line,omitempty"`
+	Line       int     `json:"line"`
+	Action     string  `json:"action,omitempty"` // created, skipped or updated
+	ThreadID   string  `json:"thread_id,omitempty"`
+	CommentURL string  `json:"comment_url,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+	Error      string  `json:"error,omitempty"`
+
+	finding sarifFinding
+}
+
+// placeSARIFFindings maps findings to the hunks of the changed files and merges findings of the same rule
+// on the same lines. The most severe come first. Returns the placed findings and the count of dropped
+// ones by reason.
+func placeSARIFFindings(findings []sarifFinding, hunks map[string][]lineRange) ([]sarifComment, map[string]int) {
+	dropped := map[string]int{}
+	seen := map[string]bool{}
+	var comments []sarifComment
+	for _, finding := range findings {
+		if finding.Path == "" || finding.StartLine <= 0 {
+			dropped["no_location"]++
+			continue
+		}
+		path, ok := matchChangedFile(finding.Path, hunks)
+		if !ok {
+			dropped["file_not_changed"]++
+			continue
+		}
+		startLine, line, ok := mapToHunk(hunks[path], finding.StartLine, finding.EndLine)
+		if !ok {
+			dropped["outside_diff"]++
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%d:%d", finding.RuleID, path, startLine, line)
+		if seen[key] {
+			dropped["duplicate_in_document"]++
+			continue
+		}
+		seen[key] = true
+
+		comment := sarifComment{RuleID: finding.RuleID, Level: finding.Level, Path: path, Line: line, finding: finding}
+		if startLine < line {
+			comment.StartLine = startLine
+		}
+		comments = append(comments, comment)
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if sarifLevelRank[a.Level] != sarifLevelRank[b.Level] {
+			return sarifLevelRank[a.Level] > sarifLevelRank[b.Level]
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	return comments, dropped
+}
+
+// PostSARIFFindings creates a tool to add the findings of a static analysis SARIF document to the pending review
+func PostSARIFFindings(getClient GetClientFn, getGQLClient GetGQLClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "post_sarif_findings"
+	description := "Add the results of a SARIF document from a static analyzer to the requester's pending review, one review thread per finding, led by its rule ID. Findings outside the pull request's changed hunks are dropped, repeated findings of a rule on the same lines are merged, and findings already commented on are skipped like in add_comment_to_pending_review. A pending review must exist, see create_pending_pull_request_review."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number", mcp.Required(), mcp.Description("Pull request number")),
+			mcp.WithString("sarif", mcp.Required(), mcp.Description("The SARIF 2.1.0 document, as JSON")),
+			mcp.WithString("min_level", mcp.Description("Least severe SARIF level to post (default: warning)"), mcp.Enum("error", "warning", "note", "none")),
+			mcp.WithNumber("max_comments", mcp.Description(fmt.Sprintf("Maximum number of findings to post, the most severe first (default: %d)", defaultSARIFMaxComments))),
+			mcp.WithString("category", mcp.Description("Category of the comments, a single lowercase word (default: static-analysis)")),
+			mcp.WithString("on_duplicate", mcp.Description("What to do when a similar comment by the requester is already on the same lines: 'skip', 'update' or 'post' (default: skip)"), mcp.Enum("skip", "update", "post")),
+			mcp.WithNumber("similarity_threshold", mcp.Description(fmt.Sprintf("Similarity from 0 to 1 above which an existing comment counts as a duplicate (default: %.1f)", defaultSimilarityThreshold))),
+			mcp.WithBoolean("dry_run", mcp.Description("Only map the findings to the diff and return them, without posting (default: false)")),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				PullNumber          int32    `mapstructure:"pull_number"`
+				SARIF               any      `mapstructure:"sarif"`
+				MinLevel            string   `mapstructure:"min_level"`
+				MaxComments         int      `mapstructure:"max_comments"`
+				Category            string   `mapstructure:"category"`
+				OnDuplicate         string   `mapstructure:"on_duplicate"`
+				SimilarityThreshold *float64 `mapstructure:"similarity_threshold"`
+				DryRun              bool     `mapstructure:"dry_run"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if params.PullNumber == 0 {
+				return mcp.NewToolResultError("pull_number is required"), nil
+			}
+			if params.MinLevel == "" {
+				params.MinLevel = "warning"
+			}
+			if _, ok := sarifLevelRank[params.MinLevel]; !ok {
+				return mcp.NewToolResultError("min_level must be one of: error, warning, note, none"), nil
+			}
+			if params.MaxComments <= 0 {
+				params.MaxComments = defaultSARIFMaxComments
+			}
+			if params.Category == "" {
+				params.Category = "static-analysis"
+			}
+			if params.OnDuplicate == "" {
+				params.OnDuplicate = "skip"
+			}
+			if params.OnDuplicate != "skip" && params.OnDuplicate != "update" && params.OnDuplicate != "post" {
+				return mcp.NewToolResultError("on_duplicate must be one of: skip, update, post"), nil
+			}
+			similarityThreshold := defaultSimilarityThreshold
+			if params.SimilarityThreshold != nil {
+				similarityThreshold = *params.SimilarityThreshold
+				if similarityThreshold < 0 || similarityThreshold > 1 {
+					return mcp.NewToolResultError("similarity_threshold must be between 0 and 1"), nil
+				}
+			}
+
+			// Clients may pass the document as a JSON object rather than a string
+			var document []byte
+			switch sarif := params.SARIF.(type) {
+			case nil:
+				return mcp.NewToolResultError("sarif is required"), nil
+			case string:
+				document = []byte(sarif)
+			default:
+				data, err := json.Marshal(sarif)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid SARIF document: %v", err)), nil
+				}
+				document = data
+			}
+
+			findings, err := parseSARIF(document)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if _, err := newCommentMeta("", params.Category); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var selected []sarifFinding
+			belowLevel := 0
+			for _, finding := range findings {
+				if sarifLevelRank[finding.Level] < sarifLevelRank[params.MinLevel] {
+					belowLevel++
+					continue
+				}
+				selected = append(selected, finding)
+			}
+
+			client, err := getClient(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get GitHub client: %v", err)), nil
+			}
+
+			files, err := fetchAllPages(ctx, func(ctx context.Context, opts github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+				return client.PullRequests.ListFiles(ctx, owner, repo, int(params.PullNumber), &opts)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to list pull request files: %v", err)), nil
+			}
+			hunks := make(map[string][]lineRange, len(files))
+			for _, file := range files {
+				if file.GetStatus() != "removed" {
+					hunks[file.GetFilename()] = diffHunks(file.GetPatch())
+				}
+			}
+
+			comments, dropped := placeSARIFFindings(selected, hunks)
+			if belowLevel > 0 {
+				dropped["below_min_level"] = belowLevel
+			}
+			if len(comments) > params.MaxComments {
+				dropped["over_max_comments"] = len(comments) - params.MaxComments
+				comments = comments[:params.MaxComments]
+			}
+
+			result := map[string]interface{}{
+				"findings": len(findings),
+				"dropped":  dropped,
+			}
+
+			if !params.DryRun && len(comments) > 0 {
+				gqlClient, err := getGQLClient(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+				}
+
+				review, err := findViewerPendingReview(ctx, gqlClient, owner, repo, int(params.PullNumber))
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				if review == nil {
+					return mcp.NewToolResultError(noPendingReviewMessage + ", create one with create_pending_pull_request_review first"), nil
+				}
+
+				var dedupe *duplicateCheck
+				if params.OnDuplicate != "post" {
+					dedupe = newDuplicateCheck(ctx, gqlClient, owner, repo, int(params.PullNumber), review.Author, params.OnDuplicate, similarityThreshold)
+				}
+
+				target := pendingReviewTarget{ID: review.ID, CommitOID: review.CommitID, PullNumber: int(params.PullNumber)}
+				side := "RIGHT"
+				counts := map[string]int{}
+				for i := range comments {
+					comment := &comments[i]
+					line := int32(comment.Line)
+					reviewComment := reviewComment{
+						Path:        comment.Path,
+						Body:        renderSARIFFinding(comment.finding),
+						SubjectType: "LINE",
+						Line:        &line,
+						Side:        &side,
+						Meta:        commentMeta{Severity: sarifLevelSeverity[comment.Level], Category: params.Category},
+					}
+					if comment.StartLine > 0 {
+						startLine := int32(comment.StartLine)
+						reviewComment.StartLine = &startLine
+						reviewComment.StartSide = &side
+					}
+
+					// Keep going on errors, so one bad position does not lose the other findings
+					added, err := addReviewComment(ctx, gqlClient, footer, toolName, owner, repo, target, reviewComment, dedupe)
+					if err != nil {
+						comment.Error = err.Error()
+						counts["failed"]++
+						continue
+					}
+					comment.Action = added.Action
+					comment.ThreadID = added.ThreadID
+					comment.CommentURL = added.CommentURL
+					if added.Action != "created" {
+						comment.Similarity = math.Round(added.Similarity*100) / 100
+					}
+					counts[added.Action]++
+				}
+
+				result["review_url"] = review.URL
+				result["created"] = counts["created"]
+				result["skipped"] = counts["skipped"]
+				result["updated"] = counts["updated"]
+				result["failed"] = counts["failed"]
+			}
+
+			result["comments"] = comments
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal SARIF findings: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(resultJSON)), nil
+		}
+}
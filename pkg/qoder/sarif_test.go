@@ -0,0 +1,153 @@
+package qoder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSARIF = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "gosec", "rules": [
+      {"id": "G101", "name": "HardcodedCredentials", "helpUri": "https://example.com/G101", "defaultConfiguration": {"level": "error"}},
+      {"id": "G104", "shortDescription": {"text": "Errors unhandled"}}
+    ]}},
+    "results": [
+      {"ruleId": "G101", "message": {"text": "Potential hardcoded credentials in {0}", "arguments": ["token"]},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "pkg/auth.go"}, "region": {"startLine": 12}}}]},
+      {"ruleIndex": 1, "level": "note", "message": {"text": ""},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go"}, "region": {"startLine": 3, "endLine": 5}}}]},
+      {"ruleId": "G104", "kind": "pass", "message": {"text": "fine"}},
+      {"ruleId": "G104", "message": {"text": "suppressed"}, "suppressions": [{"kind": "inSource"}]},
+      {"ruleId": "X1", "level": "bogus", "message": {"text": "no location"}}
+    ]
+  }]
+}`
+
+func TestParseSARIF(t *testing.T) {
+	findings, err := parseSARIF([]byte(testSARIF))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []sarifFinding{
+		{Tool: "gosec", RuleID: "G101", RuleName: "HardcodedCredentials", HelpURI: "https://example.com/G101", Level: "error", Message: "Potential hardcoded credentials in token", Path: "pkg/auth.go", StartLine: 12, EndLine: 12},
+		{Tool: "gosec", RuleID: "G104", Level: "note", Message: "Errors unhandled", Path: "main.go", StartLine: 3, EndLine: 5},
+		{Tool: "gosec", RuleID: "X1", Level: "warning", Message: "no location"},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("expected %+v, got %+v", expected, findings)
+	}
+
+	if _, err := parseSARIF([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
+
+func TestMatchChangedFile(t *testing.T) {
+	hunks := map[string][]lineRange{"pkg/auth.go": nil, "auth.go": nil, "dir with space/x.go": nil}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "relative path", path: "pkg/auth.go", expected: "pkg/auth.go"},
+		{name: "dot prefix", path: "./auth.go", expected: "auth.go"},
+		{name: "file URI of a checkout", path: "file:///home/runner/work/repo/repo/pkg/auth.go", expected: "pkg/auth.go"},
+		{name: "escaped", path: "dir%20with%20space/x.go", expected: "dir with space/x.go"},
+		{name: "windows separators", path: "C:\\src\\pkg\\auth.go", expected: "pkg/auth.go"},
+		{name: "not changed", path: "other.go", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match, ok := matchChangedFile(tc.path, hunks)
+			if match != tc.expected || ok != (tc.expected != "") {
+				t.Errorf("expected %q, got %q (%v)", tc.expected, match, ok)
+			}
+		})
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n a\n+b\n c\n d\n@@ -20,2 +21,0 @@ func x()\n-e\n-f\n@@ -40 +40 @@\n-g\n+h"
+	expected := []lineRange{{Start: 1, End: 4}, {Start: 40, End: 40}}
+	if hunks := diffHunks(patch); !reflect.DeepEqual(hunks, expected) {
+		t.Errorf("expected %v, got %v", expected, hunks)
+	}
+}
+
+func TestMapToHunk(t *testing.T) {
+	hunks := []lineRange{{Start: 10, End: 20}, {Start: 40, End: 45}}
+
+	testCases := []struct {
+		name          string
+		startLine     int
+		endLine       int
+		expectedStart int
+		expectedLine  int
+		expectedOK    bool
+	}{
+		{name: "inside a hunk", startLine: 12, endLine: 14, expectedStart: 12, expectedLine: 14, expectedOK: true},
+		{name: "clipped to the hunk", startLine: 18, endLine: 25, expectedStart: 18, expectedLine: 20, expectedOK: true},
+		{name: "starts before the hunk", startLine: 35, endLine: 41, expectedStart: 40, expectedLine: 41, expectedOK: true},
+		{name: "between hunks", startLine: 25, endLine: 30},
+		{name: "after the last hunk", startLine: 50, endLine: 50},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, line, ok := mapToHunk(hunks, tc.startLine, tc.endLine)
+			if start != tc.expectedStart || line != tc.expectedLine || ok != tc.expectedOK {
+				t.Errorf("expected (%d, %d, %v), got (%d, %d, %v)", tc.expectedStart, tc.expectedLine, tc.expectedOK, start, line, ok)
+			}
+		})
+	}
+}
+
+func TestRenderSARIFFinding(t *testing.T) {
+	body := renderSARIFFinding(sarifFinding{Tool: "gosec", RuleID: "G101", RuleName: "HardcodedCredentials", HelpURI: "https://example.com/G101", Message: "Potential hardcoded credentials"})
+	expected := "**`G101`** HardcodedCredentials (gosec)\n\nPotential hardcoded credentials\n\n[Rule documentation](https://example.com/G101)"
+	if body != expected {
+		t.Errorf("expected %q, got %q", expected, body)
+	}
+
+	if body := renderSARIFFinding(sarifFinding{Tool: "lint", Message: "bad"}); body != "**lint**\n\nbad" {
+		t.Errorf("unexpected body without rule %q", body)
+	}
+}
+
+func TestPlaceSARIFFindings(t *testing.T) {
+	hunks := map[string][]lineRange{
+		"a.go": {{Start: 1, End: 10}},
+		"b.go": {{Start: 5, End: 8}},
+	}
+	findings := []sarifFinding{
+		{RuleID: "W1", Level: "warning", Path: "b.go", StartLine: 6, EndLine: 6},
+		{RuleID: "E1", Level: "error", Path: "a.go", StartLine: 2, EndLine: 4},
+		{RuleID: "E1", Level: "error", Path: "./a.go", StartLine: 2, EndLine: 4},
+		{RuleID: "E2", Level: "error", Path: "a.go", StartLine: 30, EndLine: 30},
+		{RuleID: "E3", Level: "error", Path: "c.go", StartLine: 1, EndLine: 1},
+		{RuleID: "E4", Level: "error"},
+	}
+
+	comments, dropped := placeSARIFFindings(findings, hunks)
+
+	var placed []string
+	for _, comment := range comments {
+		placed = append(placed, strings.Join([]string{comment.RuleID, comment.Path}, "@"))
+	}
+	if expected := []string{"E1@a.go", "W1@b.go"}; !reflect.DeepEqual(placed, expected) {
+		t.Errorf("expected %v, got %v", expected, placed)
+	}
+	if comments[0].StartLine != 2 || comments[0].Line != 4 || comments[1].StartLine != 0 || comments[1].Line != 6 {
+		t.Errorf("unexpected lines %+v", comments)
+	}
+
+	expectedDropped := map[string]int{"duplicate_in_document": 1, "outside_diff": 1, "file_not_changed": 1, "no_location": 1}
+	if !reflect.DeepEqual(dropped, expectedDropped) {
+		t.Errorf("expected %v, got %v", expectedDropped, dropped)
+	}
+}
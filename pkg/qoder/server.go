@@ -2,6 +2,7 @@ package qoder
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/google/go-github/v73/github"
 	"github.com/mark3labs/mcp-go/server"
@@ -10,62 +11,105 @@ import (
 )
 
 // NewServer creates a new Qoder MCP server with the specified configuration
-func NewServer(version, token, owner, repo, runID, serverURL string) *server.MCPServer {
+func NewServer(version, token, owner, repo, runID, serverURL string, footerConfig FooterConfig) *server.MCPServer {
+	// Create the HTTP transport shared by all GitHub clients: conditional requests against a response
+	// cache on top of retries for transient failures and rate limits
+	rateLimits := newRateLimitTracker()
+	transport := newCacheTransportFromEnv(newRetryTransportFromEnv(http.DefaultTransport, rateLimits))
+	httpClient := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			Base:   transport,
+		},
+	}
+
 	// Create a new MCP server
 	s := server.NewMCPServer(
 		"qoder-github-mcp-server",
 		version,
+		server.WithToolHandlerMiddleware(rateLimitMiddleware(rateLimits)),
 	)
 
 	// Create GitHub client factory
 	getClient := func(ctx context.Context) (*github.Client, error) {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		return github.NewClient(tc), nil
+		return github.NewClient(httpClient), nil
 	}
 
 	getGQLClient := func(ctx context.Context) (*githubv4.Client, error) {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		return githubv4.NewClient(tc), nil
+		return githubv4.NewClient(httpClient), nil
 	}
 
+	// Create the footer appended to posted comments
+	footer := NewFooterFromConfig(owner, repo, runID, serverURL, footerConfig)
+
 	// Register tools
-	registerTools(s, getClient, getGQLClient, owner, repo, runID, serverURL)
+	registerTools(s, getClient, getGQLClient, footer, owner, repo)
 
 	return s
 }
 
 // registerTools registers all available tools with the MCP server
-func registerTools(s *server.MCPServer, getClient GetClientFn, getGQLClient GetGQLClientFn, owner, repo, runID, serverURL string) {
+func registerTools(s *server.MCPServer, getClient GetClientFn, getGQLClient GetGQLClientFn, footer *Footer, owner, repo string) {
 	// Register the add review line comment tool
-	addCommentTool, addCommentHandler := AddCommentToPendingReview(getClient, getGQLClient, owner, repo)
+	addCommentTool, addCommentHandler := AddCommentToPendingReview(getClient, getGQLClient, footer, owner, repo)
 	s.AddTool(addCommentTool, addCommentHandler)
 
+	// Register the post SARIF findings tool
+	sarifTool, sarifHandler := PostSARIFFindings(getClient, getGQLClient, footer, owner, repo)
+	s.AddTool(sarifTool, sarifHandler)
+
 	// Register the create pending review tool
-	createReviewTool, createReviewHandler := CreatePendingPullRequestReview(getClient, owner, repo)
+	createReviewTool, createReviewHandler := CreatePendingPullRequestReview(getClient, getGQLClient, owner, repo)
 	s.AddTool(createReviewTool, createReviewHandler)
 
+	// Register the delete pending review tool
+	deleteReviewTool, deleteReviewHandler := DeletePendingReview(getClient, getGQLClient, owner, repo)
+	s.AddTool(deleteReviewTool, deleteReviewHandler)
+
 	// Register the submit pending review tool
-	submitReviewTool, submitReviewHandler := SubmitPendingPullRequestReview(getClient, getGQLClient, owner, repo, runID, serverURL)
+	submitReviewTool, submitReviewHandler := SubmitPendingPullRequestReview(getClient, getGQLClient, footer, owner, repo)
 	s.AddTool(submitReviewTool, submitReviewHandler)
 
+	// Register the cleanup outdated bot threads tool
+	cleanupThreadsTool, cleanupThreadsHandler := CleanupOutdatedBotThreads(getGQLClient, owner, repo)
+	s.AddTool(cleanupThreadsTool, cleanupThreadsHandler)
+
+	// Register the create check run tool
+	createCheckRunTool, createCheckRunHandler := CreateCheckRun(getClient, footer, owner, repo)
+	s.AddTool(createCheckRunTool, createCheckRunHandler)
+
+	// Register the update check run tool
+	updateCheckRunTool, updateCheckRunHandler := UpdateCheckRun(getClient, owner, repo)
+	s.AddTool(updateCheckRunTool, updateCheckRunHandler)
+
 	// Register the reply comment tool
-	replyCommentTool, replyCommentHandler := ReplyComment(getClient, owner, repo, runID, serverURL)
+	replyCommentTool, replyCommentHandler := ReplyComment(getClient, footer, owner, repo)
 	s.AddTool(replyCommentTool, replyCommentHandler)
 
 	// Register the update comment tool
-	updateCommentTool, updateCommentHandler := UpdateComment(getClient, owner, repo, runID, serverURL)
+	updateCommentTool, updateCommentHandler := UpdateComment(getClient, footer, owner, repo)
 	s.AddTool(updateCommentTool, updateCommentHandler)
 
 	// Register the get PR diff tool (with line numbers and compression)
 	getPRDiffTool, getPRDiffHandler := GetPullRequestDiff(getClient, owner, repo)
 	s.AddTool(getPRDiffTool, getPRDiffHandler)
 
+	// Register the expand diff context tool
+	expandDiffContextTool, expandDiffContextHandler := ExpandDiffContext(getClient, owner, repo)
+	s.AddTool(expandDiffContextTool, expandDiffContextHandler)
+
+	// Register the list PR commits tool
+	listCommitsTool, listCommitsHandler := ListPullRequestCommits(getClient, owner, repo)
+	s.AddTool(listCommitsTool, listCommitsHandler)
+
+	// Register the get commit diff tool (with line numbers and compression)
+	getCommitDiffTool, getCommitDiffHandler := GetCommitDiff(getClient, owner, repo)
+	s.AddTool(getCommitDiffTool, getCommitDiffHandler)
+
+	// Register the compare refs tool (with line numbers and compression)
+	compareRefsTool, compareRefsHandler := CompareRefs(getClient, owner, repo)
+	s.AddTool(compareRefsTool, compareRefsHandler)
+
 	// Register the get PR files tool
 	getPRFilesTool, getPRFilesHandler := GetPullRequestFiles(getClient, owner, repo)
 	s.AddTool(getPRFilesTool, getPRFilesHandler)
@@ -74,6 +118,10 @@ func registerTools(s *server.MCPServer, getClient GetClientFn, getGQLClient GetG
 	getPullRequestTool, getPullRequestHandler := GetPullRequest(getClient, owner, repo)
 	s.AddTool(getPullRequestTool, getPullRequestHandler)
 
+	// Register the get pull request overview tool
+	getPullRequestOverviewTool, getPullRequestOverviewHandler := GetPullRequestOverview(getGQLClient, owner, repo)
+	s.AddTool(getPullRequestOverviewTool, getPullRequestOverviewHandler)
+
 	// Register the get pull request comments tool
 	getPullRequestCommentsTool, getPullRequestCommentsHandler := GetPullRequestComments(getClient, owner, repo)
 	s.AddTool(getPullRequestCommentsTool, getPullRequestCommentsHandler)
@@ -82,6 +130,14 @@ func registerTools(s *server.MCPServer, getClient GetClientFn, getGQLClient GetG
 	getPullRequestReviewsTool, getPullRequestReviewsHandler := GetPullRequestReviews(getClient, owner, repo)
 	s.AddTool(getPullRequestReviewsTool, getPullRequestReviewsHandler)
 
+	// Register the get pull request checks tool
+	getPullRequestChecksTool, getPullRequestChecksHandler := GetPullRequestChecks(getClient, owner, repo)
+	s.AddTool(getPullRequestChecksTool, getPullRequestChecksHandler)
+
+	// Register the get job logs tool
+	getJobLogsTool, getJobLogsHandler := GetJobLogs(getClient, owner, repo)
+	s.AddTool(getJobLogsTool, getJobLogsHandler)
+
 	// Register the create or update file tool
 	createOrUpdateFileTool, createOrUpdateFileHandler := CreateOrUpdateFile(getClient, owner, repo)
 	s.AddTool(createOrUpdateFileTool, createOrUpdateFileHandler)
@@ -0,0 +1,229 @@
+package qoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// suggestionInfo is the info string word that marks a fenced code block as a GitHub suggestion
+const suggestionInfo = "suggestion"
+
+// suggestionBlock describes a suggestion block found in a comment body
+type suggestionBlock struct {
+	Start      int    // Offset of the opening fence line
+	End        int    // Offset just after the closing fence (before its line ending)
+	Content    string // Code between the fences, with "\n" line endings
+	Opener     string // Opening fence line, e.g. "````suggestion"
+	Closer     string // Closing fence line, e.g. "````", or "" if the block runs to the end of the body
+	LineEnding string // Line ending used by the block, "\n" or "\r\n"
+}
+
+// codeFence describes an opening code fence line
+type codeFence struct {
+	indent int    // Spaces before the fence (0-3)
+	char   byte   // '`' or '~'
+	length int    // Number of fence characters (>= 3)
+	info   string // Info string after the fence
+}
+
+// bodyLine is a line of a comment body with its position
+type bodyLine struct {
+	text       string // Line text without line ending
+	start      int    // Offset of the first character
+	end        int    // Offset just after the text (before the line ending)
+	lineEnding string // "\n", "\r\n" or "" for the last line
+}
+
+// splitBodyLines splits a comment body into lines, keeping track of offsets and line endings
+func splitBodyLines(body string) []bodyLine {
+	var lines []bodyLine
+	start := 0
+	for start <= len(body) {
+		newline := strings.IndexByte(body[start:], '\n')
+		if newline == -1 {
+			lines = append(lines, bodyLine{text: body[start:], start: start, end: len(body)})
+			break
+		}
+
+		end := start + newline
+		lineEnding := "\n"
+		if end > start && body[end-1] == '\r' {
+			end--
+			lineEnding = "\r\n"
+		}
+		lines = append(lines, bodyLine{text: body[start:end], start: start, end: end, lineEnding: lineEnding})
+		start += newline + 1
+	}
+	return lines
+}
+
+// parseCodeFenceOpener parses a CommonMark opening code fence line
+func parseCodeFenceOpener(line string) (codeFence, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 || indent == len(line) {
+		return codeFence{}, false
+	}
+
+	char := line[indent]
+	if char != '`' && char != '~' {
+		return codeFence{}, false
+	}
+
+	length := 0
+	for indent+length < len(line) && line[indent+length] == char {
+		length++
+	}
+	if length < 3 {
+		return codeFence{}, false
+	}
+
+	info := strings.TrimSpace(line[indent+length:])
+	// The info string of a backtick fence may not contain backticks
+	if char == '`' && strings.Contains(info, "`") {
+		return codeFence{}, false
+	}
+
+	return codeFence{indent: indent, char: char, length: length, info: info}, true
+}
+
+// closes reports whether a line is a closing fence for this code fence
+// A closing fence uses the same character, is at least as long and has nothing but spaces after it
+func (f codeFence) closes(line string) bool {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return false
+	}
+
+	rest := line[indent:]
+	length := 0
+	for length < len(rest) && rest[length] == f.char {
+		length++
+	}
+	return length >= f.length && strings.TrimSpace(rest[length:]) == ""
+}
+
+// isSuggestion reports whether the fence's info string marks a suggestion block
+func (f codeFence) isSuggestion() bool {
+	fields := strings.Fields(f.info)
+	return len(fields) > 0 && fields[0] == suggestionInfo
+}
+
+// findSuggestionBlocks finds all suggestion blocks in a comment body, in order
+// Fences follow CommonMark: ``` or ~~~ of any length of at least three, an info string starting with
+// "suggestion", and a closing fence of the same character that is at least as long as the opening one.
+// Other fenced code blocks are skipped, so a suggestion example inside a code block is left alone.
+func findSuggestionBlocks(body string) []suggestionBlock {
+	lines := splitBodyLines(body)
+
+	var blocks []suggestionBlock
+	for i := 0; i < len(lines); i++ {
+		fence, ok := parseCodeFenceOpener(lines[i].text)
+		if !ok {
+			continue
+		}
+
+		// Find the closing fence; an unclosed fence runs to the end of the body
+		closing := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if fence.closes(lines[j].text) {
+				closing = j
+				break
+			}
+		}
+		closed := closing < len(lines)
+
+		if fence.isSuggestion() {
+			contentEnd := closing
+			if !closed && contentEnd > i+1 && lines[contentEnd-1].text == "" && lines[contentEnd-1].lineEnding == "" {
+				// The body's final line ending does not start another content line
+				contentEnd--
+			}
+
+			var contentLines []string
+			for _, line := range lines[i+1 : contentEnd] {
+				// Content lines lose up to as many leading spaces as the opening fence had
+				text := line.text
+				for k := 0; k < fence.indent && strings.HasPrefix(text, " "); k++ {
+					text = text[1:]
+				}
+				contentLines = append(contentLines, text)
+			}
+
+			content := ""
+			if len(contentLines) > 0 {
+				content = strings.Join(contentLines, "\n") + "\n"
+			}
+
+			block := suggestionBlock{
+				Start:      lines[i].start,
+				End:        lines[contentEnd-1].end,
+				Content:    content,
+				Opener:     lines[i].text,
+				LineEnding: lines[i].lineEnding,
+			}
+			if closed {
+				block.End = lines[closing].end
+				block.Closer = lines[closing].text
+			}
+			blocks = append(blocks, block)
+		}
+
+		i = closing
+	}
+
+	return blocks
+}
+
+// render rebuilds the suggestion block with new content, keeping the original fences and line endings
+// The fences are lengthened if the new content contains a line that would close them early
+func (b suggestionBlock) render(content string) string {
+	opener, closer := b.Opener, b.Closer
+
+	if fence, ok := parseCodeFenceOpener(opener); ok {
+		longest := 0
+		for _, line := range strings.Split(content, "\n") {
+			if fence.closes(line) {
+				trimmed := strings.TrimLeft(line, " ")
+				run := len(trimmed) - len(strings.TrimLeft(trimmed, string(fence.char)))
+				longest = max(longest, run)
+			}
+		}
+
+		if longest > 0 {
+			newFence := strings.Repeat(string(fence.char), longest+1)
+			indentation := strings.Repeat(" ", fence.indent)
+			opener = indentation + newFence + opener[fence.indent+fence.length:]
+			closer = indentation + newFence
+		}
+	}
+
+	lineEnding := b.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	content = strings.ReplaceAll(content, "\n", lineEnding)
+
+	if closer == "" {
+		// An unclosed block stays unclosed
+		return opener + lineEnding + content
+	}
+	return opener + lineEnding + content + lineEnding + closer
+}
+
+// extractSuggestionBlock extracts the content of the first suggestion block from a comment body
+func extractSuggestionBlock(body string) (string, error) {
+	blocks := findSuggestionBlocks(body)
+	if len(blocks) == 0 {
+		return "", fmt.Errorf("no suggestion block found")
+	}
+	return blocks[0].Content, nil
+}
+
+// getFullSuggestionBlock extracts the first full suggestion block, including its fences, from a comment body
+func getFullSuggestionBlock(body string) (string, error) {
+	blocks := findSuggestionBlocks(body)
+	if len(blocks) == 0 {
+		return "", fmt.Errorf("no suggestion block found")
+	}
+	return body[blocks[0].Start:blocks[0].End], nil
+}
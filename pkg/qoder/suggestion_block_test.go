@@ -0,0 +1,189 @@
+package qoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindSuggestionBlocks_Fences(t *testing.T) {
+	testCases := []struct {
+		name             string
+		body             string
+		expectedContents []string
+	}{
+		{
+			name:             "Simple backtick fence",
+			body:             "Fix:\n```suggestion\nx := 1\n```\n",
+			expectedContents: []string{"x := 1\n"},
+		},
+		{
+			name:             "Four-backtick fence containing a code fence",
+			body:             "````suggestion\nSee:\n```go\nx := 1\n```\n````",
+			expectedContents: []string{"See:\n```go\nx := 1\n```\n"},
+		},
+		{
+			name:             "Tilde fence",
+			body:             "~~~suggestion\nx := 1\n~~~",
+			expectedContents: []string{"x := 1\n"},
+		},
+		{
+			name:             "Tilde fence containing backticks",
+			body:             "~~~suggestion\n```\n~~~",
+			expectedContents: []string{"```\n"},
+		},
+		{
+			name:             "Info string after suggestion",
+			body:             "```suggestion title=\"fix\"\nx := 1\n```",
+			expectedContents: []string{"x := 1\n"},
+		},
+		{
+			name:             "CRLF line endings",
+			body:             "Fix:\r\n```suggestion\r\nx := 1\r\ny := 2\r\n```\r\n",
+			expectedContents: []string{"x := 1\ny := 2\n"},
+		},
+		{
+			name:             "Closing fence longer than opening",
+			body:             "```suggestion\nx\n`````",
+			expectedContents: []string{"x\n"},
+		},
+		{
+			name:             "Shorter fence does not close",
+			body:             "````suggestion\nx\n```\n````",
+			expectedContents: []string{"x\n```\n"},
+		},
+		{
+			name:             "Indented fence strips content indentation",
+			body:             "  ```suggestion\n    x := 1\n  ```",
+			expectedContents: []string{"  x := 1\n"},
+		},
+		{
+			name:             "Suggestion inside another code block is ignored",
+			body:             "````markdown\n```suggestion\nx\n```\n````\n```suggestion\ny\n```",
+			expectedContents: []string{"y\n"},
+		},
+		{
+			name:             "Not a suggestion",
+			body:             "```go\nx\n```",
+			expectedContents: nil,
+		},
+		{
+			name:             "Suggestion word must be the first info word",
+			body:             "```suggestions\nx\n```",
+			expectedContents: nil,
+		},
+		{
+			name:             "Unclosed suggestion runs to the end of the body",
+			body:             "Fix:\n```suggestion\nx\n  y",
+			expectedContents: []string{"x\n  y\n"},
+		},
+		{
+			name:             "Unclosed suggestion with a final line ending",
+			body:             "```suggestion\nx\n",
+			expectedContents: []string{"x\n"},
+		},
+		{
+			name:             "Unclosed suggestion after a closed one",
+			body:             "```suggestion\nx\n```\n```suggestion\ny",
+			expectedContents: []string{"x\n", "y\n"},
+		},
+		{
+			name:             "Unclosed code block swallows a later suggestion",
+			body:             "```go\nx\n~~~suggestion\ny\n~~~",
+			expectedContents: nil,
+		},
+		{
+			name:             "Four-space indented fence is not a fence",
+			body:             "    ```suggestion\n    x\n    ```",
+			expectedContents: nil,
+		},
+		{
+			name:             "Empty suggestion",
+			body:             "```suggestion\n```",
+			expectedContents: []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks := findSuggestionBlocks(tc.body)
+			if len(blocks) != len(tc.expectedContents) {
+				t.Fatalf("findSuggestionBlocks() found %d blocks; want %d", len(blocks), len(tc.expectedContents))
+			}
+			for i, block := range blocks {
+				if block.Content != tc.expectedContents[i] {
+					t.Errorf("block %d content = %q; want %q", i, block.Content, tc.expectedContents[i])
+				}
+				full := tc.body[block.Start:block.End]
+				if !strings.HasPrefix(full, block.Opener) || !strings.HasSuffix(full, block.Closer) {
+					t.Errorf("block %d range %q does not match its fences", i, full)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestionBlockRender(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Keeps the original fences",
+			body:     "````suggestion title\nx\n````",
+			content:  "\ty",
+			expected: "````suggestion title\n\ty\n````",
+		},
+		{
+			name:     "Keeps CRLF line endings",
+			body:     "```suggestion\r\nx\r\n```",
+			content:  "a\nb",
+			expected: "```suggestion\r\na\r\nb\r\n```",
+		},
+		{
+			name:     "Leaves an unclosed block unclosed",
+			body:     "```suggestion\nx\n",
+			content:  "  y",
+			expected: "```suggestion\n  y",
+		},
+		{
+			name:     "Lengthens fences that the content would close",
+			body:     "```suggestion\n    ```\n```",
+			content:  "```",
+			expected: "````suggestion\n```\n````",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks := findSuggestionBlocks(tc.body)
+			if len(blocks) != 1 {
+				t.Fatalf("findSuggestionBlocks() found %d blocks; want 1", len(blocks))
+			}
+			if result := blocks[0].render(tc.content); result != tc.expected {
+				t.Errorf("render() = %q; want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReindentSuggestionBlocks_FenceSafe(t *testing.T) {
+	body := "Two options:\r\n\r\n````suggestion\r\n    // Example:\r\n    // ```\r\n    x := 1\r\n````\r\n\r\n~~~suggestion\r\n    y := 2\r\n~~~\r\n"
+	expected := "Two options:\r\n\r\n````suggestion\r\n\t// Example:\r\n\t// ```\r\n\tx := 1\r\n````\r\n\r\n~~~suggestion\r\n\ty := 2\r\n~~~\r\n"
+
+	result := reindentSuggestionBlocks(body, "\t", indentStyle{UseTabs: true, Width: 4})
+	if result != expected {
+		t.Errorf("reindentSuggestionBlocks() = %q; want %q", result, expected)
+	}
+}
+
+func TestReindentSuggestionBlocks_Unclosed(t *testing.T) {
+	body := "Use this:\n```suggestion\n    x := 1\n        y := 2\n"
+	expected := "Use this:\n```suggestion\n\tx := 1\n\t\ty := 2\n"
+
+	result := reindentSuggestionBlocks(body, "\t", indentStyle{UseTabs: true, Width: 4})
+	if result != expected {
+		t.Errorf("reindentSuggestionBlocks() = %q; want %q", result, expected)
+	}
+}
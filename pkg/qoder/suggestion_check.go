@@ -0,0 +1,241 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// previewContextLines is the number of unchanged lines shown around a suggestion preview
+const previewContextLines = 3
+
+// suggestionValidation is the result of checking a suggestion against the lines it replaces
+type suggestionValidation struct {
+	Identical bool     `json:"identical"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Preview   string   `json:"preview"`
+}
+
+// syntaxCheck is the result of parsing a file with a suggestion applied
+type syntaxCheck struct {
+	Language string `json:"language"`
+	Valid    bool   `json:"valid"`
+	Error    string `json:"error,omitempty"`
+}
+
+// suggestionReport collects the checks run on the suggestion blocks of a comment, one entry per block
+type suggestionReport struct {
+	Validations  []suggestionValidation
+	SyntaxChecks []syntaxCheck
+}
+
+// checkSuggestions runs the requested checks on every suggestion block in a comment body against the
+// head file lines startLine..endLine (1-indexed, inclusive) that the suggestions replace
+func checkSuggestions(ctx context.Context, file *headFile, startLine, endLine int, body string, validate, checkSyntax bool) (*suggestionReport, error) {
+	report := &suggestionReport{}
+	blocks := findSuggestionBlocks(body)
+	if len(blocks) == 0 || (!validate && !checkSyntax) {
+		return report, nil
+	}
+
+	// Only the syntax of supported languages can be checked
+	language, checker := syntaxCheckerFor(file.path)
+	if !validate && checker == nil {
+		return report, nil
+	}
+
+	content, _, err := file.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fileLines := splitFileLines(content)
+	if startLine < 1 || endLine < startLine || endLine > len(fileLines) {
+		return nil, fmt.Errorf("line range %d-%d is out of range for file %s (file has %d lines)", startLine, endLine, file.path, len(fileLines))
+	}
+
+	// A file that does not parse before the suggestion says nothing about the suggestion
+	checkSyntax = checkSyntax && checker != nil && checker(content) == nil
+
+	for _, block := range blocks {
+		suggestion := trimBlankLines(block.Content)
+
+		if validate {
+			report.Validations = append(report.Validations, validateSuggestion(fileLines, startLine, endLine, suggestion))
+		}
+
+		if checkSyntax {
+			check := syntaxCheck{Language: language, Valid: true}
+			spliced := spliceSuggestion(fileLines, startLine, endLine, suggestion)
+			if err := checker(strings.Join(spliced, "\n") + "\n"); err != nil {
+				check.Valid = false
+				check.Error = err.Error()
+			}
+			report.SyntaxChecks = append(report.SyntaxChecks, check)
+		}
+	}
+
+	return report, nil
+}
+
+// splitFileLines splits file content into lines, ignoring the newline at the end of the file
+func splitFileLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// validateSuggestion checks a suggestion against the lines startLine..endLine it replaces
+// It flags suggestions identical to the original and the usual off-by-one mistakes where the
+// suggestion repeats the line just outside the range or leaves out a line at the edge of the range
+func validateSuggestion(fileLines []string, startLine, endLine int, suggestion string) suggestionValidation {
+	var suggestionLines []string
+	if suggestion != "" {
+		suggestionLines = strings.Split(suggestion, "\n")
+	}
+	originalLines := fileLines[startLine-1 : endLine]
+
+	validation := suggestionValidation{
+		Preview: buildSuggestionPreview(fileLines, startLine, endLine, suggestionLines),
+	}
+
+	if len(suggestionLines) > 0 && sameLines(suggestionLines, originalLines) {
+		validation.Identical = true
+		validation.Warnings = append(validation.Warnings, "The suggestion is identical to the lines it replaces")
+		return validation
+	}
+
+	if len(suggestionLines) == 0 {
+		return validation
+	}
+
+	firstSuggested := strings.TrimSpace(suggestionLines[0])
+	lastSuggested := strings.TrimSpace(suggestionLines[len(suggestionLines)-1])
+	firstOriginal := strings.TrimSpace(originalLines[0])
+	lastOriginal := strings.TrimSpace(originalLines[len(originalLines)-1])
+
+	// Line repeated from just before the range
+	if startLine > 1 {
+		before := strings.TrimSpace(fileLines[startLine-2])
+		if before != "" && firstSuggested == before && firstOriginal != before {
+			validation.Warnings = append(validation.Warnings, fmt.Sprintf(
+				"The suggestion starts with line %d (%q), which is just before the replaced range %d-%d and would be duplicated",
+				startLine-1, before, startLine, endLine))
+		}
+	}
+
+	// Line repeated from just after the range
+	if endLine < len(fileLines) {
+		after := strings.TrimSpace(fileLines[endLine])
+		if after != "" && lastSuggested == after && lastOriginal != after {
+			validation.Warnings = append(validation.Warnings, fmt.Sprintf(
+				"The suggestion ends with line %d (%q), which is just after the replaced range %d-%d and would be duplicated",
+				endLine+1, after, startLine, endLine))
+		}
+	}
+
+	// First or last line of the range left out of the suggestion
+	suggested := map[string]bool{}
+	for _, line := range suggestionLines {
+		suggested[strings.TrimSpace(line)] = true
+	}
+	if len(originalLines) > 1 && firstOriginal != "" && !suggested[firstOriginal] &&
+		firstSuggested == strings.TrimSpace(originalLines[1]) {
+		validation.Warnings = append(validation.Warnings, fmt.Sprintf(
+			"Line %d (%q) is replaced but missing from the suggestion, so it would be dropped; the range may start one line too early",
+			startLine, firstOriginal))
+	}
+	if len(originalLines) > 1 && lastOriginal != "" && !suggested[lastOriginal] &&
+		lastSuggested == strings.TrimSpace(originalLines[len(originalLines)-2]) {
+		validation.Warnings = append(validation.Warnings, fmt.Sprintf(
+			"Line %d (%q) is replaced but missing from the suggestion, so it would be dropped; the range may end one line too late",
+			endLine, lastOriginal))
+	}
+
+	return validation
+}
+
+// sameLines reports whether two blocks of lines are equal, ignoring trailing whitespace
+func sameLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimRight(a[i], " \t\r") != strings.TrimRight(b[i], " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+// buildSuggestionPreview shows the result of applying a suggestion in the enhanced diff format
+// Surrounding lines keep their line numbers, replaced lines are shown with "-" and suggested lines with "+"
+func buildSuggestionPreview(fileLines []string, startLine, endLine int, suggestionLines []string) string {
+	var result []string
+
+	for i := max(startLine-previewContextLines, 1); i < startLine; i++ {
+		result = append(result, fmt.Sprintf("%d  %s", i, fileLines[i-1]))
+	}
+	for i := startLine; i <= endLine; i++ {
+		result = append(result, fmt.Sprintf("   -%s", fileLines[i-1]))
+	}
+	for _, line := range suggestionLines {
+		result = append(result, fmt.Sprintf("   +%s", line))
+	}
+	for i := endLine + 1; i <= min(endLine+previewContextLines, len(fileLines)); i++ {
+		result = append(result, fmt.Sprintf("%d  %s", i, fileLines[i-1]))
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// spliceSuggestion returns the file lines with startLine..endLine replaced by the suggestion
+func spliceSuggestion(fileLines []string, startLine, endLine int, suggestion string) []string {
+	result := make([]string, 0, len(fileLines))
+	result = append(result, fileLines[:startLine-1]...)
+	if suggestion != "" {
+		result = append(result, strings.Split(suggestion, "\n")...)
+	}
+	return append(result, fileLines[endLine:]...)
+}
+
+// syntaxCheckerFor returns the language name and parser for the files we can check offline
+// Returns a nil checker for unsupported files
+func syntaxCheckerFor(filePath string) (string, func(string) error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".go":
+		return "go", func(src string) error {
+			_, err := parser.ParseFile(token.NewFileSet(), path.Base(filePath), src, parser.AllErrors)
+			return err
+		}
+	case ".json":
+		return "json", func(src string) error {
+			var v any
+			return json.Unmarshal([]byte(src), &v)
+		}
+	case ".yaml", ".yml":
+		return "yaml", func(src string) error {
+			decoder := yaml.NewDecoder(strings.NewReader(src))
+			for {
+				var v any
+				if err := decoder.Decode(&v); err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return err
+				}
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,248 @@
+package qoder
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v73/github"
+)
+
+var suggestionCheckFile = []string{
+	"func main() {",   // 1
+	"\tx := load()",   // 2
+	"\tif x == nil {", // 3
+	"\t\treturn",      // 4
+	"\t}",             // 5
+	"\tprocess(x)",    // 6
+	"}",               // 7
+}
+
+func TestValidateSuggestion(t *testing.T) {
+	testCases := []struct {
+		name             string
+		startLine        int
+		endLine          int
+		suggestion       string
+		identical        bool
+		expectedWarnings []string // substrings, one per expected warning
+	}{
+		{
+			name:       "Valid replacement",
+			startLine:  4,
+			endLine:    4,
+			suggestion: "\t\tlog.Fatal(\"x is nil\")",
+		},
+		{
+			name:       "Identical to original",
+			startLine:  3,
+			endLine:    5,
+			suggestion: "\tif x == nil {\n\t\treturn\n\t}",
+			identical:  true,
+		},
+		{
+			name:       "Identical ignoring trailing whitespace",
+			startLine:  6,
+			endLine:    6,
+			suggestion: "\tprocess(x)  ",
+			identical:  true,
+		},
+		{
+			name:             "Repeats the line before the range",
+			startLine:        3,
+			endLine:          5,
+			suggestion:       "\tx := load()\n\tif x == nil {\n\t\treturn errNil\n\t}",
+			expectedWarnings: []string{"starts with line 2"},
+		},
+		{
+			name:             "Repeats the line after the range",
+			startLine:        3,
+			endLine:          5,
+			suggestion:       "\tif x == nil {\n\t\treturn errNil\n\t}\n\tprocess(x)",
+			expectedWarnings: []string{"ends with line 6"},
+		},
+		{
+			name:             "Drops the first line of the range",
+			startLine:        2,
+			endLine:          4,
+			suggestion:       "\tif x == nil {\n\t\treturn errNil",
+			expectedWarnings: []string{"Line 2", "start one line too early"},
+		},
+		{
+			name:             "Drops the last line of the range",
+			startLine:        4,
+			endLine:          6,
+			suggestion:       "\t\treturn errNil\n\t}",
+			expectedWarnings: []string{"Line 6", "end one line too late"},
+		},
+		{
+			name:       "Deletion suggestion",
+			startLine:  6,
+			endLine:    6,
+			suggestion: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validateSuggestion(suggestionCheckFile, tc.startLine, tc.endLine, tc.suggestion)
+			if result.Identical != tc.identical {
+				t.Errorf("Identical = %v; want %v", result.Identical, tc.identical)
+			}
+			if tc.identical {
+				return
+			}
+			joined := strings.Join(result.Warnings, "\n")
+			if len(tc.expectedWarnings) == 0 && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+			for _, expected := range tc.expectedWarnings {
+				if !strings.Contains(joined, expected) {
+					t.Errorf("warnings %v do not contain %q", result.Warnings, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSuggestionPreview(t *testing.T) {
+	preview := buildSuggestionPreview(suggestionCheckFile, 4, 4, []string{"\t\treturn err"})
+	expected := strings.Join([]string{
+		"1  func main() {",
+		"2  \tx := load()",
+		"3  \tif x == nil {",
+		"   -\t\treturn",
+		"   +\t\treturn err",
+		"5  \t}",
+		"6  \tprocess(x)",
+		"7  }",
+	}, "\n")
+
+	if preview != expected {
+		t.Errorf("buildSuggestionPreview() =\n%s\nwant\n%s", preview, expected)
+	}
+}
+
+func TestSplitFileLines(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected int
+	}{
+		{"a\nb\n", 2},
+		{"a\nb", 2},
+		{"", 1},
+		{"\n", 1},
+	}
+
+	for _, tc := range testCases {
+		if result := splitFileLines(tc.content); len(result) != tc.expected {
+			t.Errorf("splitFileLines(%q) has %d lines; want %d", tc.content, len(result), tc.expected)
+		}
+	}
+}
+
+func TestSpliceSuggestion(t *testing.T) {
+	lines := []string{"a", "b", "c", "d"}
+
+	testCases := []struct {
+		name       string
+		startLine  int
+		endLine    int
+		suggestion string
+		expected   []string
+	}{
+		{"Replace one line", 2, 2, "B", []string{"a", "B", "c", "d"}},
+		{"Replace range with more lines", 2, 3, "B1\nB2\nC", []string{"a", "B1", "B2", "C", "d"}},
+		{"Delete lines", 1, 2, "", []string{"c", "d"}},
+		{"Replace last line", 4, 4, "D", []string{"a", "b", "c", "D"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := spliceSuggestion(lines, tc.startLine, tc.endLine, tc.suggestion)
+			if strings.Join(result, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("spliceSuggestion() = %q; want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSyntaxCheckerFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		src      string
+		language string
+		valid    bool
+	}{
+		{"Valid Go", "main.go", "package main\n\nfunc main() {\n\tprintln(1)\n}\n", "go", true},
+		{"Broken Go", "main.go", "package main\n\nfunc main() {\n\tprintln(1\n}\n", "go", false},
+		{"Valid JSON", "config.json", `{"a": [1, 2]}`, "json", true},
+		{"Broken JSON", "config.json", `{"a": [1, 2}`, "json", false},
+		{"Valid YAML", "ci.yml", "a:\n  b: 1\n---\nc: 2\n", "yaml", true},
+		{"Broken YAML", "ci.yaml", "a:\n  b: 1\n c: 2\n", "yaml", false},
+		{"Unsupported", "script.py", "def f(:\n", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			language, checker := syntaxCheckerFor(tc.path)
+			if language != tc.language {
+				t.Fatalf("syntaxCheckerFor(%s) language = %q; want %q", tc.path, language, tc.language)
+			}
+			if checker == nil {
+				if tc.language != "" {
+					t.Fatalf("syntaxCheckerFor(%s) returned nil checker", tc.path)
+				}
+				return
+			}
+			if err := checker(tc.src); (err == nil) != tc.valid {
+				t.Errorf("checker(%q) error = %v; want valid = %v", tc.src, err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestHeadFileFetchedOnce(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString([]byte(strings.Join(suggestionCheckFile, "\n") + "\n"))
+	requests := 0
+	client := github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		body := `{"head": {"sha": "abc123"}}`
+		if strings.Contains(req.URL.Path, "/contents/") {
+			body = `{"type": "file", "encoding": "base64", "content": "` + content + `"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})})
+
+	file := newHeadFile(client, "owner", "repo", 1, "main.go")
+	body := "```suggestion\n    process(x, true)\n```"
+	adjusted, err := adjustSuggestionIndentation(context.Background(), file, 6, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adjusted != "```suggestion\n\tprocess(x, true)\n```" {
+		t.Errorf("unexpected adjusted body %q", adjusted)
+	}
+
+	report, err := checkSuggestions(context.Background(), file, 6, 6, adjusted, true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Validations) != 1 || report.Validations[0].Identical {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// One request for the pull request and one for the file, shared by both steps
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
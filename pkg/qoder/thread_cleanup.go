@@ -0,0 +1,180 @@
+package qoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shurcooL/githubv4"
+)
+
+// cleanupThread describes an outdated thread found by cleanup_outdated_bot_threads
+type cleanupThread struct {
+	ThreadID string `json:"thread_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"original_line,omitempty"`
+	URL      string `json:"url"`
+	Reason   string `json:"reason"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// outdatedThreadReason tells why a thread is outdated at head, or "" if it is not
+func outdatedThreadReason(thread reviewThread) string {
+	if thread.IsOutdated {
+		return "outdated"
+	}
+	if thread.SubjectType != "FILE" && thread.Line == 0 {
+		return "lines_removed"
+	}
+	return ""
+}
+
+// selectOutdatedThreads returns the submitted threads started by author that are outdated at head and
+// not already cleaned up by action
+func selectOutdatedThreads(threads []reviewThread, author, action string) []reviewThread {
+	var outdated []reviewThread
+	for _, thread := range threads {
+		// Pending comments cannot be resolved or minimized, they belong to the review being written
+		if thread.CommentState == "PENDING" || !sameLogin(thread.Author, author) {
+			continue
+		}
+		if thread.IsResolved || (action == "minimize" && thread.IsMinimized) {
+			continue
+		}
+		if outdatedThreadReason(thread) != "" {
+			outdated = append(outdated, thread)
+		}
+	}
+	return outdated
+}
+
+// CleanupOutdatedBotThreads creates a tool to resolve or minimize our review threads that no longer apply
+func CleanupOutdatedBotThreads(getGQLClient GetGQLClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+	toolName := "cleanup_outdated_bot_threads"
+	description := "Find unresolved review threads started by the current user (the bot) that are outdated because the pull request changed, or whose commented lines no longer exist at head, and resolve or minimize them. Use dry_run to list them without changing anything."
+
+	return mcp.NewTool(toolName,
+			mcp.WithDescription(description),
+			mcp.WithNumber("pull_number",
+				mcp.Required(),
+				mcp.Description("Pull request number"),
+			),
+			mcp.WithString("action",
+				mcp.Description("'resolve' marks the threads as resolved, 'minimize' hides their first comment as outdated (default: resolve)"),
+				mcp.Enum("resolve", "minimize"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only list the threads that would be cleaned up (default: false)"),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var params struct {
+				PullNumber int32  `mapstructure:"pull_number"`
+				Action     string `mapstructure:"action"`
+				DryRun     bool   `mapstructure:"dry_run"`
+			}
+			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if params.Action == "" {
+				params.Action = "resolve"
+			}
+
+			client, err := getGQLClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+			}
+
+			// Our threads are the ones started by the current user
+			var getViewerQuery struct {
+				Viewer struct {
+					Login githubv4.String
+				}
+			}
+			if err := client.Query(ctx, &getViewerQuery, nil); err != nil {
+				return NewGitHubGraphQLErrorResponse(ctx,
+					"failed to get current user",
+					err,
+				), nil
+			}
+
+			threads, err := listReviewThreads(ctx, client, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return NewGitHubGraphQLErrorResponse(ctx,
+					"failed to get review threads",
+					err,
+				), nil
+			}
+
+			outdated := selectOutdatedThreads(threads, string(getViewerQuery.Viewer.Login), params.Action)
+			results := make([]cleanupThread, 0, len(outdated))
+			cleaned := 0
+			for _, thread := range outdated {
+				result := cleanupThread{
+					ThreadID: fmt.Sprintf("%v", thread.ID),
+					Path:     thread.Path,
+					Line:     thread.OriginalLine,
+					URL:      thread.CommentURL,
+					Reason:   outdatedThreadReason(thread),
+					Status:   "would_" + params.Action,
+				}
+
+				if !params.DryRun {
+					if err := cleanupReviewThread(ctx, client, thread, params.Action); err != nil {
+						result.Status = "failed"
+						result.Error = err.Error()
+					} else {
+						result.Status = "resolved"
+						if params.Action == "minimize" {
+							result.Status = "minimized"
+						}
+						cleaned++
+					}
+				}
+
+				results = append(results, result)
+			}
+
+			response := map[string]interface{}{
+				"dry_run": params.DryRun,
+				"action":  params.Action,
+				"found":   len(results),
+				"cleaned": cleaned,
+				"threads": results,
+			}
+			responseJSON, _ := json.Marshal(response)
+			return mcp.NewToolResultText(string(responseJSON)), nil
+		}
+}
+
+// cleanupReviewThread resolves a review thread or minimizes its first comment as outdated
+func cleanupReviewThread(ctx context.Context, client *githubv4.Client, thread reviewThread, action string) error {
+	if action == "minimize" {
+		var minimizeMutation struct {
+			MinimizeComment struct {
+				MinimizedComment struct {
+					IsMinimized githubv4.Boolean
+				}
+			} `graphql:"minimizeComment(input: $input)"`
+		}
+		return client.Mutate(ctx, &minimizeMutation, githubv4.MinimizeCommentInput{
+			SubjectID:  thread.CommentID,
+			Classifier: githubv4.ReportedContentClassifiersOutdated,
+		}, nil)
+	}
+
+	var resolveMutation struct {
+		ResolveReviewThread struct {
+			Thread struct {
+				ID githubv4.ID
+			}
+		} `graphql:"resolveReviewThread(input: $input)"`
+	}
+	return client.Mutate(ctx, &resolveMutation, githubv4.ResolveReviewThreadInput{
+		ThreadID: thread.ID,
+	}, nil)
+}
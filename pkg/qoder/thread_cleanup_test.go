@@ -0,0 +1,62 @@
+package qoder
+
+import (
+	"testing"
+)
+
+func TestSelectOutdatedThreads(t *testing.T) {
+	threads := []reviewThread{
+		{ID: "outdated", Author: "qoder", SubjectType: "LINE", IsOutdated: true, OriginalLine: 5},
+		{ID: "lines-removed", Author: "qoder", SubjectType: "LINE", OriginalLine: 8},
+		{ID: "current", Author: "qoder", SubjectType: "LINE", Line: 10},
+		{ID: "file-level", Author: "qoder", SubjectType: "FILE"},
+		{ID: "resolved", Author: "qoder", SubjectType: "LINE", IsOutdated: true, IsResolved: true},
+		{ID: "minimized", Author: "qoder", SubjectType: "LINE", IsOutdated: true, IsMinimized: true},
+		{ID: "pending", Author: "qoder", SubjectType: "LINE", IsOutdated: true, CommentState: "PENDING"},
+		{ID: "human", Author: "octocat", SubjectType: "LINE", IsOutdated: true},
+	}
+
+	testCases := []struct {
+		name     string
+		action   string
+		expected []string
+	}{
+		{name: "resolve", action: "resolve", expected: []string{"outdated", "lines-removed", "minimized"}},
+		{name: "minimize skips minimized threads", action: "minimize", expected: []string{"outdated", "lines-removed"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := selectOutdatedThreads(threads, "qoder[bot]", tc.action)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("got %d threads, expected %d", len(got), len(tc.expected))
+			}
+			for i, thread := range got {
+				if thread.ID != tc.expected[i] {
+					t.Errorf("thread %d: got %v, expected %s", i, thread.ID, tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOutdatedThreadReason(t *testing.T) {
+	testCases := []struct {
+		name     string
+		thread   reviewThread
+		expected string
+	}{
+		{name: "outdated", thread: reviewThread{SubjectType: "LINE", IsOutdated: true, Line: 3}, expected: "outdated"},
+		{name: "lines removed", thread: reviewThread{SubjectType: "LINE"}, expected: "lines_removed"},
+		{name: "current line", thread: reviewThread{SubjectType: "LINE", Line: 3}, expected: ""},
+		{name: "file level", thread: reviewThread{SubjectType: "FILE"}, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := outdatedThreadReason(tc.thread); got != tc.expected {
+				t.Errorf("outdatedThreadReason() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
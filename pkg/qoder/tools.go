@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/google/go-github/v73/github"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/qoder/qoder-github-mcp-server/pkg/fixlink"
 	"github.com/shurcooL/githubv4"
 )
 
@@ -21,39 +23,11 @@ type GetClientFn func(context.Context) (*github.Client, error)
 // GetGQLClientFn is a function type for getting a GitHub GraphQL client
 type GetGQLClientFn func(context.Context) (*githubv4.Client, error)
 
-// buildActionFooter creates a footer with a link to GitHub Actions run
-func buildActionFooter(owner, repo, runID, serverURL string) string {
-	if runID == "" || serverURL == "" {
-		return `
-
----
-🤖 Generated by [Qoder](https://qoder.com/)`
-	}
-
-	// Build the GitHub Actions run URL
-	actionURL := fmt.Sprintf("%s/%s/%s/actions/runs/%s", serverURL, owner, repo, runID)
-	return fmt.Sprintf(`
-
----
-🤖 Generated by [Qoder](https://qoder.com/) • [View workflow run](%s)`, actionURL)
-}
-
 // QoderFixContext holds the context for a one-click Qoder fix
-type QoderFixContext struct {
-	Owner      string `json:"owner"`
-	Repo       string `json:"repo"`
-	PullNumber int    `json:"pull_number"`
-	CommitID   string `json:"commit_id"`
-	Path       string `json:"path"`
-	Line       int    `json:"line"`
-	Side       string `json:"side"`
-	StartLine  int    `json:"start_line,omitempty"`
-	StartSide  string `json:"start_side,omitempty"`
-	Body       string `json:"body"`
-}
+type QoderFixContext = fixlink.Context
 
 // AddCommentToPendingReview creates a tool to add a review comment to a pending review
-func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "add_comment_to_pending_review"
 	description := "Add review comment to the requester's latest pending pull request review."
 
@@ -67,6 +41,12 @@ func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientF
 			mcp.WithString("side", mcp.Description("The side of the diff to comment on. LEFT indicates the previous state, RIGHT indicates the new state"), mcp.Enum("LEFT", "RIGHT")),
 			mcp.WithNumber("start_line", mcp.Description("For multi-line comments, the first line of the range that the comment applies to")),
 			mcp.WithString("start_side", mcp.Description("For multi-line comments, the starting side of the diff that the comment applies to. LEFT indicates the previous state, RIGHT indicates the new state"), mcp.Enum("LEFT", "RIGHT")),
+			mcp.WithBoolean("validate_suggestion", mcp.Description("Check suggestion blocks against the lines they replace before posting. Rejects suggestions identical to the original, warns when a suggestion repeats or drops the lines around the range, and returns a preview of the resulting code (default: false)")),
+			mcp.WithString("syntax_check", mcp.Description("Apply suggestion blocks to the head file and parse the result before posting (Go, JSON and YAML files). 'warn' posts the comment and reports parse errors, 'reject' refuses to post a suggestion that breaks the file, 'off' skips the check (default: warn)"), mcp.Enum("off", "warn", "reject")),
+			mcp.WithString("severity", mcp.Description("Severity of the issue, shown as a badge and stored as hidden metadata"), mcp.Enum("blocker", "major", "minor", "nit")),
+			mcp.WithString("category", mcp.Description("Category of the issue, e.g. security, performance, correctness, maintainability, style, documentation or testing. Shown as a badge and stored as hidden metadata")),
+			mcp.WithString("on_duplicate", mcp.Description("What to do when an unresolved thread by the same author on the same lines already has a similar comment: 'skip' posts nothing and returns the existing thread, 'update' replaces the existing comment's text, 'post' always adds a new comment (default: skip)"), mcp.Enum("skip", "update", "post")),
+			mcp.WithNumber("similarity_threshold", mcp.Description(fmt.Sprintf("Similarity from 0 to 1 above which an existing comment counts as a duplicate (default: %.1f)", defaultSimilarityThreshold))),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var params struct {
@@ -78,16 +58,43 @@ func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientF
 				Side        *string `mapstructure:"side"`
 				StartLine   *int32  `mapstructure:"start_line"`
 				StartSide   *string `mapstructure:"start_side"`
+
+				ValidateSuggestion bool    `mapstructure:"validate_suggestion"`
+				SyntaxCheck        *string `mapstructure:"syntax_check"`
+				Severity           string  `mapstructure:"severity"`
+				Category           string  `mapstructure:"category"`
+
+				OnDuplicate         string   `mapstructure:"on_duplicate"`
+				SimilarityThreshold *float64 `mapstructure:"similarity_threshold"`
 			}
 			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			meta, err := newCommentMeta(params.Severity, params.Category)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if params.OnDuplicate == "" {
+				params.OnDuplicate = "skip"
+			}
+			similarityThreshold := defaultSimilarityThreshold
+			if params.SimilarityThreshold != nil {
+				similarityThreshold = *params.SimilarityThreshold
+				if similarityThreshold < 0 || similarityThreshold > 1 {
+					return mcp.NewToolResultError("similarity_threshold must be between 0 and 1"), nil
+				}
+			}
+
 			restClient, err := getClient(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get GitHub REST client: %w", err)
 			}
 
+			// Indentation and suggestion checks share one fetch of the head file
+			file := newHeadFile(restClient, owner, repo, int(params.PullNumber), params.Path)
+
 			// Adjust suggestion indentation if a suggestion block exists
 			var adjustedBody string
 			if params.Line != nil {
@@ -97,7 +104,7 @@ func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientF
 					// Multi-line comment: align to startLine
 					targetLine = int(*params.StartLine)
 				}
-				adjustedBody, err = adjustSuggestionIndentation(ctx, restClient, owner, repo, int(params.PullNumber), params.Path, targetLine, params.Body)
+				adjustedBody, err = adjustSuggestionIndentation(ctx, file, targetLine, params.Body)
 				if err != nil {
 					// If adjustment fails, log the error and proceed with the original body
 					// This ensures that the comment is still added even if indentation adjustment fails
@@ -109,196 +116,154 @@ func AddCommentToPendingReview(getClient GetClientFn, getGQLClient GetGQLClientF
 				adjustedBody = params.Body
 			}
 
-			client, err := getGQLClient(ctx)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+			// Check suggestions against the head file lines they replace
+			syntaxCheckMode := "warn"
+			if params.SyntaxCheck != nil && *params.SyntaxCheck != "" {
+				syntaxCheckMode = *params.SyntaxCheck
 			}
-
-			// First we'll get the current user
-			var getViewerQuery struct {
-				Viewer struct {
-					Login githubv4.String
+			report := &suggestionReport{}
+			if params.Line != nil && (params.Side == nil || *params.Side == "RIGHT") {
+				startLine := int(*params.Line)
+				if params.StartLine != nil {
+					startLine = int(*params.StartLine)
+				}
+				report, err = checkSuggestions(ctx, file, startLine, int(*params.Line), adjustedBody, params.ValidateSuggestion, syntaxCheckMode != "off")
+				if err != nil {
+					if params.ValidateSuggestion || syntaxCheckMode == "reject" {
+						return mcp.NewToolResultError(fmt.Sprintf("failed to check suggestion: %v", err)), nil
+					}
+					// A best-effort syntax check must not block the comment
+					fmt.Fprintf(os.Stderr, "Failed to check suggestion syntax: %v\n", err)
+					report = &suggestionReport{}
 				}
-			}
 
-			if err := client.Query(ctx, &getViewerQuery, nil); err != nil {
-				return NewGitHubGraphQLErrorResponse(ctx,
-					"failed to get current user",
-					err,
-				), nil
-			}
+				for _, validation := range report.Validations {
+					if validation.Identical {
+						errorInfo := map[string]interface{}{
+							"error":   "identical_suggestion",
+							"message": "The suggestion is identical to the lines it replaces and would not change anything.",
+							"preview": validation.Preview,
+						}
+						errorJSON, _ := json.Marshal(errorInfo)
+						return mcp.NewToolResultError(string(errorJSON)), nil
+					}
+				}
 
-			var getLatestReviewForViewerQuery struct {
-				Repository struct {
-					PullRequest struct {
-						Reviews struct {
-							Nodes []struct {
-								ID    githubv4.ID
-								State githubv4.PullRequestReviewState
-								URL   githubv4.URI
+				if syntaxCheckMode == "reject" {
+					for _, check := range report.SyntaxChecks {
+						if !check.Valid {
+							errorInfo := map[string]interface{}{
+								"error":    "suggestion_syntax_error",
+								"message":  fmt.Sprintf("The file no longer parses as %s with the suggestion applied.", check.Language),
+								"details":  check.Error,
+								"language": check.Language,
 							}
-						} `graphql:"reviews(first: 1, author: $author, states: PENDING)"`
-					} `graphql:"pullRequest(number: $prNum)"`
-				} `graphql:"repository(owner: $owner, name: $name)"`
-			}
-
-			vars := map[string]any{
-				"author": githubv4.String(getViewerQuery.Viewer.Login),
-				"owner":  githubv4.String(owner),
-				"name":   githubv4.String(repo),
-				"prNum":  githubv4.Int(params.PullNumber),
-			}
-
-			if err := client.Query(context.Background(), &getLatestReviewForViewerQuery, vars); err != nil {
-				return NewGitHubGraphQLErrorResponse(ctx,
-					"failed to get latest review for current user",
-					err,
-				), nil
-			}
-
-			// Validate there is one review and the state is pending
-			if len(getLatestReviewForViewerQuery.Repository.PullRequest.Reviews.Nodes) == 0 {
-				return mcp.NewToolResultError("No pending review found for the viewer"), nil
+							errorJSON, _ := json.Marshal(errorInfo)
+							return mcp.NewToolResultError(string(errorJSON)), nil
+						}
+					}
+				}
 			}
 
-			review := getLatestReviewForViewerQuery.Repository.PullRequest.Reviews.Nodes[0]
-			if review.State != githubv4.PullRequestReviewStatePending {
-				errText := fmt.Sprintf("The latest review, found at %s is not pending", review.URL)
-				return mcp.NewToolResultError(errText), nil
+			client, err := getGQLClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
 			}
 
-			// Create QoderFixContext for the footer link
-			fixContext := QoderFixContext{
-				Owner:      owner,
-				Repo:       repo,
-				PullNumber: int(params.PullNumber),
-				Path:       params.Path,
-				Body:       adjustedBody, // Use adjusted body here
+			review, err := findViewerPendingReview(ctx, client, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
 			}
-
-			if params.Line != nil {
-				fixContext.Line = int(*params.Line)
-			}
-			if params.Side != nil {
-				fixContext.Side = *params.Side
-			}
-			if params.StartLine != nil {
-				fixContext.StartLine = int(*params.StartLine)
-			}
-			if params.StartSide != nil {
-				fixContext.StartSide = *params.StartSide
-			}
-
-			// Marshal and encode the context for the footer
-			// contextJSON, err := json.Marshal(fixContext)
-			// if err != nil {
-			// 	return mcp.NewToolResultError(fmt.Sprintf("failed to marshal context: %v", err)), nil
-			// }
-			// encodedContext := base64.StdEncoding.EncodeToString(contextJSON)
-			// 			footer := fmt.Sprintf(`
-
-			// ---
-			// *Powered by Qoder* | [One-Click Qoder Fix](http://localhost:9080/reload-to-qoder?context=%s)`, encodedContext)
-			footer := `
-
----
-🤖 Generated by [Qoder](https://qoder.com/)`
-			fullBody := adjustedBody + footer
-
-			// Then we can create a new review thread comment on the review.
-			var addPullRequestReviewThreadMutation struct {
-				AddPullRequestReviewThread struct {
-					Thread struct {
-						ID       githubv4.ID
-						Comments struct {
-							Nodes []struct {
-								ID           githubv4.ID
-								Body         githubv4.String
-								Path         githubv4.String
-								Line         *githubv4.Int
-								OriginalLine *githubv4.Int
-							}
-						} `graphql:"comments(first: 1)"`
-					}
-				} `graphql:"addPullRequestReviewThread(input: $input)"`
-			}
-
-			// For single-line LINE comments, GitHub requires both startLine and line to be set
-			// If startLine is not provided but line is, set startLine = line
-			effectiveStartLine := params.StartLine
-			effectiveStartSide := params.StartSide
-
-			if params.SubjectType == "LINE" && params.Line != nil {
-				if params.StartLine == nil {
-					// Single-line comment: set startLine to the same as line
-					effectiveStartLine = params.Line
-					effectiveStartSide = params.Side
-				} else if params.StartLine != nil && *params.Line > *params.StartLine {
-					// Multi-line comment: check if the range is too large (likely spans different chunks)
-					lineSpan := *params.Line - *params.StartLine
-					if lineSpan >= 10 {
-						// Return error for likely cross-chunk multi-line comments
-						errorInfo := map[string]interface{}{
-							"error":      "invalid_multiline_range",
-							"message":    "Multi-line comment range is too large and likely spans different diff chunks. GitHub does not allow comments across different chunks.",
-							"start_line": *params.StartLine,
-							"end_line":   *params.Line,
-							"line_span":  lineSpan,
-						}
-						errorJSON, _ := json.Marshal(errorInfo)
-						return mcp.NewToolResultError(string(errorJSON)), nil
+			if review == nil {
+				return mcp.NewToolResultError(noPendingReviewMessage), nil
+			}
+
+			// Multi-line comment: check if the range is too large (likely spans different chunks)
+			if params.SubjectType == "LINE" && params.Line != nil && params.StartLine != nil && *params.Line > *params.StartLine {
+				lineSpan := *params.Line - *params.StartLine
+				if lineSpan >= 10 {
+					// Return error for likely cross-chunk multi-line comments
+					errorInfo := map[string]interface{}{
+						"error":      "invalid_multiline_range",
+						"message":    "Multi-line comment range is too large and likely spans different diff chunks. GitHub does not allow comments across different chunks.",
+						"start_line": *params.StartLine,
+						"end_line":   *params.Line,
+						"line_span":  lineSpan,
 					}
+					errorJSON, _ := json.Marshal(errorInfo)
+					return mcp.NewToolResultError(string(errorJSON)), nil
 				}
 			}
 
-			if err := client.Mutate(
-				ctx,
-				&addPullRequestReviewThreadMutation,
-				githubv4.AddPullRequestReviewThreadInput{
-					Path:                githubv4.String(params.Path),
-					Body:                githubv4.String(fullBody),
-					SubjectType:         newGQLStringlikePtr[githubv4.PullRequestReviewThreadSubjectType](&params.SubjectType),
-					Line:                newGQLIntPtr(params.Line),
-					Side:                newGQLStringlikePtr[githubv4.DiffSide](params.Side),
-					StartLine:           newGQLIntPtr(effectiveStartLine),
-					StartSide:           newGQLStringlikePtr[githubv4.DiffSide](effectiveStartSide),
-					PullRequestReviewID: &review.ID,
+			// Look for a similar comment already posted by us on the same lines
+			var dedupe *duplicateCheck
+			if params.OnDuplicate != "post" {
+				dedupe = newDuplicateCheck(ctx, client, owner, repo, int(params.PullNumber), review.Author, params.OnDuplicate, similarityThreshold)
+			}
+
+			added, err := addReviewComment(ctx, client, footer, toolName, owner, repo,
+				pendingReviewTarget{ID: review.ID, CommitOID: review.CommitID, PullNumber: int(params.PullNumber)},
+				reviewComment{
+					Path:        params.Path,
+					Body:        adjustedBody, // Use adjusted body here
+					SubjectType: params.SubjectType,
+					Line:        params.Line,
+					Side:        params.Side,
+					StartLine:   params.StartLine,
+					StartSide:   params.StartSide,
+					Meta:        meta,
 				},
-				nil,
-			); err != nil {
+				dedupe,
+			)
+			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			thread := addPullRequestReviewThreadMutation.AddPullRequestReviewThread.Thread
-
-			// Verify mutation succeeded
-			if thread.ID == "" {
-				return mcp.NewToolResultError(fmt.Sprintf(
-					"Failed to create comment thread. The position (path=%s, line=%v, side=%v) may not be valid in the PR diff.",
-					params.Path, params.Line, params.Side)), nil
+			if added.Action != "created" {
+				result := map[string]interface{}{
+					"duplicate":   true,
+					"thread_id":   added.ThreadID,
+					"comment_id":  added.CommentID,
+					"comment_url": added.CommentURL,
+					"path":        added.Path,
+					"similarity":  math.Round(added.Similarity*100) / 100,
+					"action":      added.Action,
+				}
+				resultJSON, _ := json.Marshal(result)
+				return mcp.NewToolResultText(string(resultJSON)), nil
 			}
 
 			// Build success response
 			result := map[string]interface{}{
-				"thread_id": fmt.Sprintf("%v", thread.ID),
-				"path":      params.Path,
+				"thread_id": added.ThreadID,
+				"path":      added.Path,
 			}
 
-			if len(thread.Comments.Nodes) > 0 {
-				comment := thread.Comments.Nodes[0]
-				result["comment_id"] = fmt.Sprintf("%v", comment.ID)
-				if comment.Line != nil {
-					result["line"] = int(*comment.Line)
+			if added.CommentID != "" {
+				result["comment_id"] = added.CommentID
+				if added.Line != 0 {
+					result["line"] = added.Line
 				}
 			}
 
+			if !meta.isEmpty() {
+				result["meta"] = meta
+			}
+
+			if len(report.Validations) > 0 {
+				result["suggestion_validation"] = report.Validations
+			}
+			if len(report.SyntaxChecks) > 0 {
+				result["syntax_check"] = report.SyntaxChecks
+			}
+
 			resultJSON, _ := json.Marshal(result)
 			return mcp.NewToolResultText(string(resultJSON)), nil
 		}
 }
 
 // SubmitPendingPullRequestReview creates a tool to submit a pending pull request review
-func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLClientFn, owner, repo, runID, serverURL string) (mcp.Tool, server.ToolHandlerFunc) {
+func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "submit_pending_pull_request_review"
 	description := "Submit the requester's latest pending pull request review with a specific event type (APPROVE, REQUEST_CHANGES, or COMMENT)"
 
@@ -307,12 +272,19 @@ func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLCl
 			mcp.WithNumber("pull_number", mcp.Required(), mcp.Description("Pull request number")),
 			mcp.WithString("event", mcp.Required(), mcp.Description("Review action: APPROVE, REQUEST_CHANGES, or COMMENT"), mcp.Enum("APPROVE", "REQUEST_CHANGES", "COMMENT")),
 			mcp.WithString("body", mcp.Description("Summary comment for the review (optional)")),
+			mcp.WithBoolean("include_summary", mcp.Description("Insert a summary of the review's comments above the body: counts by severity tag ([blocker], [major], [minor], [nit]), files touched and a table linking to each comment (default: false)")),
+			mcp.WithBoolean("dismiss_previous", mcp.Description("When the event is APPROVE or COMMENT, dismiss the requester's earlier REQUEST_CHANGES reviews on this pull request so they stop blocking the merge (default: false)")),
+			mcp.WithString("dismiss_message", mcp.Description("Message shown on the dismissed reviews (default: a link to the new review)")),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var params struct {
-				PullNumber int32   `mapstructure:"pull_number"`
-				Event      string  `mapstructure:"event"`
-				Body       *string `mapstructure:"body"`
+				PullNumber     int32   `mapstructure:"pull_number"`
+				Event          string  `mapstructure:"event"`
+				Body           *string `mapstructure:"body"`
+				IncludeSummary bool    `mapstructure:"include_summary"`
+
+				DismissPrevious bool   `mapstructure:"dismiss_previous"`
+				DismissMessage  string `mapstructure:"dismiss_message"`
 			}
 			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
@@ -323,57 +295,34 @@ func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLCl
 				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
 			}
 
-			// Get the current user
-			var getViewerQuery struct {
-				Viewer struct {
-					Login githubv4.String
-				}
-			}
-
-			if err := gqlClient.Query(ctx, &getViewerQuery, nil); err != nil {
-				return NewGitHubGraphQLErrorResponse(ctx,
-					"failed to get current user",
-					err,
-				), nil
-			}
-
-			// Get the latest pending review for the current user
-			var getLatestReviewQuery struct {
-				Repository struct {
-					PullRequest struct {
-						Reviews struct {
-							Nodes []struct {
-								DatabaseID int64
-								State      githubv4.PullRequestReviewState
-								URL        githubv4.URI
-							}
-						} `graphql:"reviews(first: 1, author: $author, states: PENDING)"`
-					} `graphql:"pullRequest(number: $prNum)"`
-				} `graphql:"repository(owner: $owner, name: $name)"`
+			// Get the current user's pending review
+			review, err := findViewerPendingReview(ctx, gqlClient, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
 			}
-
-			vars := map[string]any{
-				"author": githubv4.String(getViewerQuery.Viewer.Login),
-				"owner":  githubv4.String(owner),
-				"name":   githubv4.String(repo),
-				"prNum":  githubv4.Int(params.PullNumber),
+			if review == nil {
+				return mcp.NewToolResultError(noPendingReviewMessage), nil
 			}
+			reviewID := review.DatabaseID
 
-			if err := gqlClient.Query(ctx, &getLatestReviewQuery, vars); err != nil {
-				return NewGitHubGraphQLErrorResponse(ctx,
-					"failed to get pending review",
-					err,
-				), nil
-			}
+			// Put the summary of the review's comments above the body
+			if params.IncludeSummary {
+				comments, err := fetchPendingReviewComments(ctx, gqlClient, review.ID)
+				if err != nil {
+					return NewGitHubGraphQLErrorResponse(ctx,
+						"failed to get pending review comments",
+						err,
+					), nil
+				}
 
-			// Validate there is a pending review
-			if len(getLatestReviewQuery.Repository.PullRequest.Reviews.Nodes) == 0 {
-				return mcp.NewToolResultError("No pending review found for the current user"), nil
+				if summary := buildReviewSummary(comments); summary != "" {
+					if params.Body != nil && *params.Body != "" {
+						summary += "\n\n" + *params.Body
+					}
+					params.Body = &summary
+				}
 			}
 
-			review := getLatestReviewQuery.Repository.PullRequest.Reviews.Nodes[0]
-			reviewID := review.DatabaseID
-
 			// Use REST API to submit the review (GraphQL SubmitPullRequestReview is not available in go-github)
 			restClient, err := getClient(ctx)
 			if err != nil {
@@ -382,16 +331,15 @@ func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLCl
 
 			// Add footer to body if provided
 			var bodyWithFooter *string
+			footerData := footer.Data(toolName, int(params.PullNumber), review.CommitID)
 			if params.Body != nil && *params.Body != "" {
-				footer := buildActionFooter(owner, repo, runID, serverURL)
-				fullBody := *params.Body + footer
+				fullBody := footer.Append(*params.Body, footerData)
 				bodyWithFooter = &fullBody
-			} else if runID != "" && serverURL != "" {
+			} else if footer.RunURL() != "" {
 				// If no body provided but we have action info, use only footer
-				footer := buildActionFooter(owner, repo, runID, serverURL)
-				// Remove leading newlines from footer when it's the only content
-				trimmedFooter := strings.TrimPrefix(footer, "\n\n")
-				bodyWithFooter = &trimmedFooter
+				if footerOnly := footer.Append("", footerData); footerOnly != "" {
+					bodyWithFooter = &footerOnly
+				}
 			}
 
 			// Submit the review
@@ -412,13 +360,30 @@ func SubmitPendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLCl
 				"review_id": submittedReview.GetID(),
 				"state":     submittedReview.GetState(),
 			}
+
+			// Earlier change requests no longer apply once the new review approves or only comments
+			if params.DismissPrevious && params.Event != "REQUEST_CHANGES" {
+				message := params.DismissMessage
+				if message == "" {
+					message = fmt.Sprintf(defaultDismissMessage, submittedReview.GetHTMLURL())
+				}
+
+				dismissals, err := dismissPreviousChangeRequests(ctx, restClient, owner, repo, int(params.PullNumber), review.Author, submittedReview.GetID(), message)
+				if err != nil {
+					// The review is already submitted, report the failure instead of failing the call
+					result["dismiss_error"] = err.Error()
+				} else {
+					result["dismissed_reviews"] = dismissals
+				}
+			}
+
 			resultJSON, _ := json.Marshal(result)
 			return mcp.NewToolResultText(string(resultJSON)), nil
 		}
 }
 
 // CreatePendingPullRequestReview creates a tool to create a new pending pull request review
-func CreatePendingPullRequestReview(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
+func CreatePendingPullRequestReview(getClient GetClientFn, getGQLClient GetGQLClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "create_pending_pull_request_review"
 	description := "Create a new pending pull request review."
 
@@ -426,21 +391,67 @@ func CreatePendingPullRequestReview(getClient GetClientFn, owner, repo string) (
 			mcp.WithDescription(description),
 			mcp.WithNumber("pull_number", mcp.Required(), mcp.Description("Pull request number")),
 			mcp.WithString("commitId", mcp.Description("The SHA of the commit to review. If not provided, defaults to the most recent commit in the pull request")),
+			mcp.WithString("on_existing", mcp.Description("What to do when the requester already has a pending review on the pull request: 'reuse' returns the existing review, 'replace' deletes it with its comments and creates a new one, 'fail' returns an error (default: fail)"), mcp.Enum("reuse", "replace", "fail")),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var params struct {
 				PullNumber int32   `mapstructure:"pull_number"`
 				CommitId   *string `mapstructure:"commitId"`
+				OnExisting string  `mapstructure:"on_existing"`
 			}
 			if err := mapstructure.Decode(request.Params.Arguments, &params); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			if params.OnExisting == "" {
+				params.OnExisting = "fail"
+			}
 
 			client, err := getClient(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get GitHub client: %w", err)
 			}
 
+			gqlClient, err := getGQLClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get GitHub GQL client: %w", err)
+			}
+
+			// GitHub allows only one pending review per user and pull request
+			existing, err := findViewerPendingReview(ctx, gqlClient, owner, repo, int(params.PullNumber))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			replaced := false
+			if existing != nil {
+				switch params.OnExisting {
+				case "reuse":
+					result := map[string]interface{}{
+						"review_id":     existing.DatabaseID,
+						"state":         "PENDING",
+						"commit_id":     existing.CommitID,
+						"comment_count": existing.CommentCount,
+						"reused":        true,
+					}
+					resultJSON, _ := json.Marshal(result)
+					return mcp.NewToolResultText(string(resultJSON)), nil
+				case "replace":
+					if _, _, err := client.PullRequests.DeletePendingReview(ctx, owner, repo, int(params.PullNumber), existing.DatabaseID); err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("failed to delete existing pending review: %v", err)), nil
+					}
+					replaced = true
+				default:
+					errorInfo := map[string]interface{}{
+						"error":         "pending_review_exists",
+						"message":       "A pending review already exists for the current user. Use on_existing 'reuse' to continue it, 'replace' to start over, or delete_pending_review.",
+						"review_id":     existing.DatabaseID,
+						"comment_count": existing.CommentCount,
+					}
+					errorJSON, _ := json.Marshal(errorInfo)
+					return mcp.NewToolResultError(string(errorJSON)), nil
+				}
+			}
+
 			// Create the review request (without Event field for pending review)
 			reviewRequest := &github.PullRequestReviewRequest{}
 
@@ -460,6 +471,9 @@ func CreatePendingPullRequestReview(getClient GetClientFn, owner, repo string) (
 				"review_id": review.GetID(),
 				"state":     review.GetState(),
 			}
+			if replaced {
+				result["replaced_review_id"] = existing.DatabaseID
+			}
 			resultJSON, _ := json.Marshal(result)
 			return mcp.NewToolResultText(string(resultJSON)), nil
 		}
@@ -533,7 +547,7 @@ func NewGitHubGraphQLErrorResponse(ctx context.Context, message string, err erro
 }
 
 // ReplyComment creates a tool to reply to an existing comment (issue or review)
-func ReplyComment(getClient GetClientFn, owner, repo, runID, serverURL string) (mcp.Tool, server.ToolHandlerFunc) {
+func ReplyComment(getClient GetClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "reply_comment"
 	description := "Reply to an existing GitHub comment (issue comment or review comment)"
 
@@ -577,8 +591,11 @@ func ReplyComment(getClient GetClientFn, owner, repo, runID, serverURL string) (
 			}
 
 			// Add footer to body
-			footer := buildActionFooter(owner, repo, runID, serverURL)
-			fullBody := body + footer
+			pullNumber := getOptionalNumberParam(request, "pull_number")
+			if commentType == "issue" {
+				pullNumber = getOptionalNumberParam(request, "issue_number")
+			}
+			fullBody := footer.Append(body, footer.Data(toolName, pullNumber, ""))
 
 			// Get GitHub client
 			client, err := getClient(ctx)
@@ -607,7 +624,7 @@ func ReplyComment(getClient GetClientFn, owner, repo, runID, serverURL string) (
 }
 
 // UpdateComment creates a tool to update an existing comment's full content
-func UpdateComment(getClient GetClientFn, owner, repo, runID, serverURL string) (mcp.Tool, server.ToolHandlerFunc) {
+func UpdateComment(getClient GetClientFn, footer *Footer, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "update_comment"
 	description := "Update an existing GitHub comment (issue comment or review comment) with new content"
 
@@ -645,8 +662,7 @@ func UpdateComment(getClient GetClientFn, owner, repo, runID, serverURL string)
 			}
 
 			// Add footer to body
-			footer := buildActionFooter(owner, repo, runID, serverURL)
-			fullBody := body + footer
+			fullBody := footer.Append(body, footer.Data(toolName, 0, ""))
 
 			// Get GitHub client
 			client, err := getClient(ctx)
@@ -814,31 +830,40 @@ func GetPullRequestDiff(getClient GetClientFn, owner, repo string) (mcp.Tool, se
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR diff: %v", err)), nil
 			}
 
-			// Add line numbers to the diff to show the latest file state
-			enhancedDiff, err := addLineNumbersToNewLines(string(rawDiff))
+			// Add line numbers and compress the diff
+			result, err := enhanceAndCompressDiff(string(rawDiff))
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("failed to enhance diff: %v", err)), nil
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			// Check if compression is enabled (default: true)
-			compressEnabled := true
-			if envVal := os.Getenv("PR_DIFF_COMPRESS_ENABLED"); envVal == "false" {
-				compressEnabled = false
-			}
+			return mcp.NewToolResultText(result), nil
+		}
+}
 
-			// Apply compression if enabled
-			if compressEnabled {
-				compressor := NewDiffCompressor()
-				compressedDiff, err := compressor.CompressDiff(enhancedDiff)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("failed to compress diff: %v", err)), nil
-				}
-				return mcp.NewToolResultText(compressedDiff), nil
-			}
+// isCompressionEnabled reports whether diff compression is enabled (default: true)
+func isCompressionEnabled() bool {
+	return os.Getenv("PR_DIFF_COMPRESS_ENABLED") != "false"
+}
 
-			// Return the enhanced diff without compression
-			return mcp.NewToolResultText(enhancedDiff), nil
-		}
+// enhanceAndCompressDiff adds line numbers to a raw diff and compresses it when enabled
+func enhanceAndCompressDiff(rawDiff string) (string, error) {
+	// Add line numbers to the diff to show the latest file state
+	enhancedDiff, err := addLineNumbersToNewLines(rawDiff)
+	if err != nil {
+		return "", fmt.Errorf("failed to enhance diff: %w", err)
+	}
+
+	// Return the enhanced diff without compression
+	if !isCompressionEnabled() {
+		return enhancedDiff, nil
+	}
+
+	compressor := NewDiffCompressor()
+	compressedDiff, err := compressor.CompressDiff(enhancedDiff)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress diff: %w", err)
+	}
+	return compressedDiff, nil
 }
 
 // addLineNumbersToNewLines adds line numbers to new lines and context lines in diff
@@ -978,6 +1003,9 @@ func GetPullRequestFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, s
 			mcp.WithNumber("per_page",
 				mcp.Description("Number of items per page (default: 30, max: 100)"),
 			),
+			mcp.WithBoolean("all",
+				mcp.Description("Fetch every page at once instead of a single page; page and per_page are ignored (default: false)"),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Extract required parameters
@@ -1007,16 +1035,38 @@ func GetPullRequestFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, s
 			}
 
 			// Fetch pull request files from GitHub API
-			opts := &github.ListOptions{
-				Page:    page,
-				PerPage: perPage,
+			listFiles := func(ctx context.Context, opts github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+				return client.PullRequests.ListFiles(ctx, owner, repo, pullNumber, &opts)
 			}
 
-			files, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, pullNumber, opts)
+			var files []*github.CommitFile
+			hasNext := false
+			totalCount, totalKnown := 0, false
+			if request.GetBool("all", false) {
+				files, err = fetchAllPages(ctx, listFiles)
+				page, perPage = 1, maxPerPage
+				totalCount, totalKnown = len(files), true
+			} else {
+				var resp *github.Response
+				files, resp, err = listFiles(ctx, github.ListOptions{Page: page, PerPage: perPage})
+				if err == nil {
+					hasNext = resp.NextPage > 0
+					totalCount, totalKnown = pageTotal(page, perPage, len(files), resp)
+				}
+			}
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR files: %v", err)), nil
 			}
 
+			// The pages tell the total when they reach the end; otherwise the PR metadata has the
+			// number of changed files
+			if !totalKnown {
+				totalCount = len(files)
+				if pr, _, err := client.PullRequests.Get(ctx, owner, repo, pullNumber); err == nil {
+					totalCount = pr.GetChangedFiles()
+				}
+			}
+
 			// Enhance patch content with line numbers
 			for _, file := range files {
 				if file.Patch != nil && *file.Patch != "" {
@@ -1029,12 +1079,7 @@ func GetPullRequestFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, s
 			}
 
 			// Apply compression if enabled
-			compressEnabled := true
-			if envVal := os.Getenv("PR_DIFF_COMPRESS_ENABLED"); envVal == "false" {
-				compressEnabled = false
-			}
-
-			if compressEnabled {
+			if isCompressionEnabled() {
 				fileCompressor := NewFileListCompressor()
 				files = fileCompressor.CompressFileList(files)
 			}
@@ -1050,8 +1095,8 @@ func GetPullRequestFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, s
 				Files:      files,
 				Page:       page,
 				PerPage:    perPage,
-				HasNext:    resp.NextPage > 0,
-				TotalCount: len(files),
+				HasNext:    hasNext,
+				TotalCount: totalCount,
 			}
 
 			// Marshal to JSON and return
@@ -1075,6 +1120,10 @@ func GetPullRequest(getClient GetClientFn, owner, repo string) (mcp.Tool, server
 				mcp.Required(),
 				mcp.Description("Pull request number"),
 			),
+			mcp.WithString("view",
+				mcp.Description("Response shape: 'minimal' keeps what is needed to act, 'review' adds the body, labels, reviewers, mergeability and counts, 'full' returns the raw GitHub API object (default: minimal)"),
+				mcp.Enum("minimal", "review", "full"),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Extract parameters
@@ -1083,6 +1132,11 @@ func GetPullRequest(getClient GetClientFn, owner, repo string) (mcp.Tool, server
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			view, err := parseView(request.GetString("view", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Get GitHub client
 			client, err := getClient(ctx)
 			if err != nil {
@@ -1096,7 +1150,7 @@ func GetPullRequest(getClient GetClientFn, owner, repo string) (mcp.Tool, server
 			}
 
 			// Marshal to JSON and return
-			resultJSON, err := json.Marshal(pr)
+			resultJSON, err := json.Marshal(pullRequestView(pr, view))
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal PR: %v", err)), nil
 			}
@@ -1122,6 +1176,13 @@ func GetPullRequestComments(getClient GetClientFn, owner, repo string) (mcp.Tool
 			mcp.WithNumber("per_page",
 				mcp.Description("Number of items per page (default: 30, max: 100)"),
 			),
+			mcp.WithBoolean("all",
+				mcp.Description("Fetch every page at once instead of a single page; page and per_page are ignored (default: false)"),
+			),
+			mcp.WithString("view",
+				mcp.Description("Response shape: 'minimal' keeps what is needed to act, 'review' adds the review, commit, original lines, diff hunk, URL and timestamps, 'full' returns the raw GitHub API object (default: minimal)"),
+				mcp.Enum("minimal", "review", "full"),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Extract required parameters
@@ -1130,6 +1191,11 @@ func GetPullRequestComments(getClient GetClientFn, owner, repo string) (mcp.Tool
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			view, err := parseView(request.GetString("view", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Extract optional pagination parameters
 			page := getOptionalNumberParam(request, "page")
 			if page == 0 {
@@ -1151,31 +1217,51 @@ func GetPullRequestComments(getClient GetClientFn, owner, repo string) (mcp.Tool
 			}
 
 			// Fetch review comments from GitHub API
-			opts := &github.PullRequestListCommentsOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: perPage,
-				},
+			listComments := func(ctx context.Context, opts github.ListOptions) ([]*github.PullRequestComment, *github.Response, error) {
+				return client.PullRequests.ListComments(ctx, owner, repo, pullNumber, &github.PullRequestListCommentsOptions{ListOptions: opts})
 			}
 
-			comments, resp, err := client.PullRequests.ListComments(ctx, owner, repo, pullNumber, opts)
+			var comments []*github.PullRequestComment
+			hasNext := false
+			totalCount, totalKnown := 0, false
+			if request.GetBool("all", false) {
+				comments, err = fetchAllPages(ctx, listComments)
+				page, perPage = 1, maxPerPage
+				totalCount, totalKnown = len(comments), true
+			} else {
+				var resp *github.Response
+				comments, resp, err = listComments(ctx, github.ListOptions{Page: page, PerPage: perPage})
+				if err == nil {
+					hasNext = resp.NextPage > 0
+					totalCount, totalKnown = pageTotal(page, perPage, len(comments), resp)
+				}
+			}
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR comments: %v", err)), nil
 			}
 
+			// The pages tell the total when they reach the end; otherwise the PR metadata has the
+			// number of review comments
+			if !totalKnown {
+				totalCount = len(comments)
+				if pr, _, err := client.PullRequests.Get(ctx, owner, repo, pullNumber); err == nil {
+					totalCount = pr.GetReviewComments()
+				}
+			}
+
 			// Create response structure with pagination info
 			result := struct {
-				Comments   []*github.PullRequestComment `json:"comments"`
-				Page       int                          `json:"page"`
-				PerPage    int                          `json:"per_page"`
-				HasNext    bool                         `json:"has_next"`
-				TotalCount int                          `json:"total_count"`
+				Comments   any  `json:"comments"`
+				Page       int  `json:"page"`
+				PerPage    int  `json:"per_page"`
+				HasNext    bool `json:"has_next"`
+				TotalCount int  `json:"total_count"`
 			}{
-				Comments:   comments,
+				Comments:   reviewCommentsView(comments, view),
 				Page:       page,
 				PerPage:    perPage,
-				HasNext:    resp.NextPage > 0,
-				TotalCount: len(comments),
+				HasNext:    hasNext,
+				TotalCount: totalCount,
 			}
 
 			// Marshal to JSON and return
@@ -1205,6 +1291,13 @@ func GetPullRequestReviews(getClient GetClientFn, owner, repo string) (mcp.Tool,
 			mcp.WithNumber("per_page",
 				mcp.Description("Number of items per page (default: 30, max: 100)"),
 			),
+			mcp.WithBoolean("all",
+				mcp.Description("Fetch every page at once instead of a single page; page and per_page are ignored (default: false)"),
+			),
+			mcp.WithString("view",
+				mcp.Description("Response shape: 'minimal' keeps what is needed to act, 'review' adds the commit, author association and URL, 'full' returns the raw GitHub API object (default: minimal)"),
+				mcp.Enum("minimal", "review", "full"),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Extract required parameters
@@ -1213,6 +1306,11 @@ func GetPullRequestReviews(getClient GetClientFn, owner, repo string) (mcp.Tool,
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			view, err := parseView(request.GetString("view", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Extract optional pagination parameters
 			page := getOptionalNumberParam(request, "page")
 			if page == 0 {
@@ -1234,29 +1332,48 @@ func GetPullRequestReviews(getClient GetClientFn, owner, repo string) (mcp.Tool,
 			}
 
 			// Fetch reviews from GitHub API
-			opts := &github.ListOptions{
-				Page:    page,
-				PerPage: perPage,
+			listReviews := func(ctx context.Context, opts github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+				return client.PullRequests.ListReviews(ctx, owner, repo, pullNumber, &opts)
 			}
 
-			reviews, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, pullNumber, opts)
+			var reviews []*github.PullRequestReview
+			hasNext := false
+			totalCount := 0
+			if request.GetBool("all", false) {
+				reviews, err = fetchAllPages(ctx, listReviews)
+				page, perPage = 1, maxPerPage
+				totalCount = len(reviews)
+			} else {
+				var resp *github.Response
+				reviews, resp, err = listReviews(ctx, github.ListOptions{Page: page, PerPage: perPage})
+				if err == nil {
+					hasNext = resp.NextPage > 0
+					var ok bool
+					if totalCount, ok = pageTotal(page, perPage, len(reviews), resp); !ok {
+						// The PR metadata has no review count, so count them from the Link header
+						if totalCount, err = countItems(ctx, listReviews); err != nil {
+							totalCount, err = len(reviews), nil
+						}
+					}
+				}
+			}
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get PR reviews: %v", err)), nil
 			}
 
 			// Create response structure with pagination info
 			result := struct {
-				Reviews    []*github.PullRequestReview `json:"reviews"`
-				Page       int                         `json:"page"`
-				PerPage    int                         `json:"per_page"`
-				HasNext    bool                        `json:"has_next"`
-				TotalCount int                         `json:"total_count"`
+				Reviews    any  `json:"reviews"`
+				Page       int  `json:"page"`
+				PerPage    int  `json:"per_page"`
+				HasNext    bool `json:"has_next"`
+				TotalCount int  `json:"total_count"`
 			}{
-				Reviews:    reviews,
+				Reviews:    reviewsView(reviews, view),
 				Page:       page,
 				PerPage:    perPage,
-				HasNext:    resp.NextPage > 0,
-				TotalCount: len(reviews),
+				HasNext:    hasNext,
+				TotalCount: totalCount,
 			}
 
 			// Marshal to JSON and return
@@ -1359,7 +1476,7 @@ func CreateOrUpdateFile(getClient GetClientFn, owner, repo string) (mcp.Tool, se
 // PushFiles creates a tool to push multiple files in a single commit to a GitHub repository
 func PushFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, server.ToolHandlerFunc) {
 	toolName := "push_files"
-	description := "Push multiple files to a GitHub repository in a single commit"
+	description := "Push multiple files to a GitHub repository in a single commit, adding, changing, deleting or renaming each of them"
 
 	return mcp.NewTool(toolName,
 			mcp.WithDescription(description),
@@ -1373,19 +1490,38 @@ func PushFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, server.Tool
 					map[string]interface{}{
 						"type":                 "object",
 						"additionalProperties": false,
-						"required":             []string{"path", "content"},
+						"required":             []string{"path"},
 						"properties": map[string]interface{}{
 							"path": map[string]interface{}{
 								"type":        "string",
 								"description": "path to the file",
 							},
+							"action": map[string]interface{}{
+								"type":        "string",
+								"enum":        pushFileActions,
+								"description": "upsert writes content to the file, delete removes it, rename moves previous_path to path, with content if given (default: upsert)",
+							},
 							"content": map[string]interface{}{
 								"type":        "string",
-								"description": "file content",
+								"description": "file content, required for upsert; the link target for symlinks",
+							},
+							"previous_path": map[string]interface{}{
+								"type":        "string",
+								"description": "path the file is renamed from, required for rename",
+							},
+							"mode": map[string]interface{}{
+								"type":        "string",
+								"enum":        pushFileModes,
+								"description": "100644 for a regular file, 100755 for an executable, 120000 for a symlink (default: the file's current mode, or 100644 for new files)",
+							},
+							"encoding": map[string]interface{}{
+								"type":        "string",
+								"enum":        []string{"utf-8", "base64"},
+								"description": "encoding of content, base64 for binary files (default: utf-8)",
 							},
 						},
 					}),
-				mcp.Description("Array of file objects to push, each object with path (string) and content (string)"),
+				mcp.Description("Array of file objects to push, each with a path and an optional action (upsert, delete or rename), content, previous_path, mode and encoding"),
 			),
 			mcp.WithString("message",
 				mcp.Required(),
@@ -1410,6 +1546,23 @@ func PushFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, server.Tool
 				return mcp.NewToolResultError("files parameter must be an array of objects with path and content"), nil
 			}
 
+			files := make([]pushFile, 0, len(filesObj))
+			for _, file := range filesObj {
+				fileMap, ok := file.(map[string]interface{})
+				if !ok {
+					return mcp.NewToolResultError("each file must be an object with path and content"), nil
+				}
+
+				var f pushFile
+				if err := mapstructure.Decode(fileMap, &f); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid file: %v", err)), nil
+				}
+				if err := f.validate(); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				files = append(files, f)
+			}
+
 			// Get GitHub client
 			client, err := getClient(ctx)
 			if err != nil {
@@ -1428,32 +1581,35 @@ func PushFiles(getClient GetClientFn, owner, repo string) (mcp.Tool, server.Tool
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get base commit: %v", err)), nil
 			}
 
-			// Create tree entries for all files
-			var entries []*github.TreeEntry
-
-			for _, file := range filesObj {
-				fileMap, ok := file.(map[string]interface{})
-				if !ok {
-					return mcp.NewToolResultError("each file must be an object with path and content"), nil
+			// Look up the files already on the branch for deletes, renames and their modes
+			var existing map[string]*github.TreeEntry
+			complete := false
+			if needsBaseTree(files) {
+				existing, complete, err = lookupTreeEntries(ctx, client, owner, repo, baseCommit.GetTree().GetSHA(), pushFilePaths(files))
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to get base tree: %v", err)), nil
 				}
+			}
 
-				path, ok := fileMap["path"].(string)
-				if !ok || path == "" {
-					return mcp.NewToolResultError("each file must have a path"), nil
+			// Binary content goes through a blob, as tree entries only take text
+			for i := range files {
+				if files[i].Content == nil || files[i].Encoding != "base64" {
+					continue
 				}
-
-				content, ok := fileMap["content"].(string)
-				if !ok {
-					return mcp.NewToolResultError("each file must have content"), nil
+				blob, _, err := client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+					Content:  files[i].Content,
+					Encoding: github.Ptr("base64"),
+				})
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to create blob for %s: %v", files[i].Path, err)), nil
 				}
+				files[i].blobSHA = blob.GetSHA()
+			}
 
-				// Create a tree entry for the file
-				entries = append(entries, &github.TreeEntry{
-					Path:    github.Ptr(path),
-					Mode:    github.Ptr("100644"), // Regular file mode
-					Type:    github.Ptr("blob"),
-					Content: github.Ptr(content),
-				})
+			// Create tree entries for all files
+			entries, err := pushTreeEntries(files, existing, complete)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 
 			// Create a new tree with the file entries
@@ -0,0 +1,378 @@
+package qoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultMaxRetries      = 3
+	defaultMaxRetryWait    = 60 * time.Second
+	baseRetryBackoff       = time.Second
+	secondaryRateLimitWait = 60 * time.Second
+
+	// lowQuotaRatio is the share of the rate limit below which tool results carry a warning
+	lowQuotaRatio = 0.1
+)
+
+// ====== Rate Limit Tracking ======
+
+// rateLimitStatus is the last known quota of a GitHub API rate limit resource
+type rateLimitStatus struct {
+	Resource  string    `json:"resource"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// rateLimitTracker records the quota reported by the X-RateLimit-* headers of GitHub responses
+type rateLimitTracker struct {
+	mu     sync.Mutex
+	latest map[string]rateLimitStatus
+}
+
+// newRateLimitTracker creates an empty rate limit tracker
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{latest: map[string]rateLimitStatus{}}
+}
+
+// update records the quota reported by a response, if any
+func (t *rateLimitTracker) update(resp *http.Response) {
+	limit, errLimit := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if errLimit != nil || errRemaining != nil {
+		return
+	}
+
+	status := rateLimitStatus{
+		Resource:  resp.Header.Get("X-RateLimit-Resource"),
+		Limit:     limit,
+		Remaining: remaining,
+	}
+	if status.Resource == "" {
+		status.Resource = "core"
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		status.Reset = time.Unix(reset, 0).UTC()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.latest[status.Resource] = status
+}
+
+// snapshot returns the last known quota of every resource, sorted by resource name
+func (t *rateLimitTracker) snapshot() []rateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]rateLimitStatus, 0, len(t.latest))
+	for _, status := range t.latest {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Resource < statuses[j].Resource })
+	return statuses
+}
+
+// lowQuotaWarning describes the resources whose remaining quota is low, or returns "" if none is
+func lowQuotaWarning(statuses []rateLimitStatus) string {
+	var warnings []string
+	for _, status := range statuses {
+		if status.Limit > 0 && float64(status.Remaining) < float64(status.Limit)*lowQuotaRatio {
+			warnings = append(warnings, fmt.Sprintf("%s: %d of %d requests left, resets at %s",
+				status.Resource, status.Remaining, status.Limit, status.Reset.Format("15:04:05 UTC")))
+		}
+	}
+	if len(warnings) == 0 {
+		return ""
+	}
+	return "Note: GitHub API rate limit is running low (" + strings.Join(warnings, "; ") + "). Avoid unnecessary calls."
+}
+
+// rateLimitMiddleware adds the current GitHub API quota to every tool result, in the result metadata and,
+// when the quota is low, as a warning the agent can read
+func rateLimitMiddleware(tracker *rateLimitTracker) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if result == nil {
+				return result, err
+			}
+
+			statuses := tracker.snapshot()
+			if len(statuses) == 0 {
+				return result, err
+			}
+
+			if result.Meta == nil {
+				result.Meta = map[string]any{}
+			}
+			result.Meta["rate_limit"] = statuses
+
+			if warning := lowQuotaWarning(statuses); warning != "" {
+				result.Content = append(result.Content, mcp.NewTextContent(warning))
+			}
+			return result, err
+		}
+	}
+}
+
+// ====== Retry Transport ======
+
+// retryTransport retries GitHub requests that failed for transient reasons: 5xx responses to requests that
+// are safe to repeat, and primary, secondary and GraphQL rate limits. Waits honor Retry-After and
+// X-RateLimit-Reset; a wait longer than maxWait is not attempted and the response is returned as is.
+type retryTransport struct {
+	base       http.RoundTripper
+	tracker    *rateLimitTracker
+	maxRetries int
+	maxWait    time.Duration
+
+	// sleep and now are replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+}
+
+// newRetryTransport creates a retry transport on top of base
+func newRetryTransport(base http.RoundTripper, tracker *rateLimitTracker, maxRetries int, maxWait time.Duration) *retryTransport {
+	return &retryTransport{
+		base:       base,
+		tracker:    tracker,
+		maxRetries: maxRetries,
+		maxWait:    maxWait,
+		sleep:      sleepContext,
+		now:        time.Now,
+	}
+}
+
+// newRetryTransportFromEnv creates a retry transport configured by the QODER_HTTP_MAX_RETRIES and
+// QODER_HTTP_MAX_RETRY_WAIT (seconds) environment variables
+func newRetryTransportFromEnv(base http.RoundTripper, tracker *rateLimitTracker) *retryTransport {
+	maxRetries := defaultMaxRetries
+	if value := os.Getenv("QODER_HTTP_MAX_RETRIES"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			maxRetries = n
+		}
+	}
+
+	maxWait := defaultMaxRetryWait
+	if value := os.Getenv("QODER_HTTP_MAX_RETRY_WAIT"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			maxWait = time.Duration(n) * time.Second
+		}
+	}
+
+	return newRetryTransport(base, tracker, maxRetries, maxWait)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		if t.tracker != nil {
+			t.tracker.update(resp)
+		}
+
+		// A request body that cannot be sent again rules out a retry
+		if attempt >= t.maxRetries || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		wait, retry := t.retryDelay(req, resp, attempt)
+		if !retry {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		fmt.Fprintf(os.Stderr, "GitHub API %s %s returned %d, retrying in %s (attempt %d of %d)\n",
+			req.Method, req.URL.Path, resp.StatusCode, wait, attempt+1, t.maxRetries)
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay decides whether a response should be retried and how long to wait first
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	var wait time.Duration
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if d, ok := t.rateLimitWait(resp); ok {
+			wait = d
+		} else if strings.Contains(strings.ToLower(peekBody(resp)), "secondary rate limit") {
+			wait = secondaryRateLimitWait
+		} else {
+			// A plain 403 is a permission problem, not a rate limit
+			return 0, false
+		}
+
+	case resp.StatusCode >= 500 && resp.StatusCode <= 504 && resp.StatusCode != http.StatusNotImplemented:
+		// Repeating a write after a server error could apply it twice
+		if !isIdempotentRequest(req) {
+			return 0, false
+		}
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), t.now()); ok {
+			wait = d
+		} else {
+			wait = baseRetryBackoff << attempt
+		}
+
+	case resp.StatusCode == http.StatusOK && isGraphQLRequest(req):
+		// GraphQL reports rate limits as errors in a successful response
+		if !isGraphQLRateLimited(peekBody(resp)) {
+			return 0, false
+		}
+		if d, ok := t.rateLimitWait(resp); ok {
+			wait = d
+		} else {
+			wait = secondaryRateLimitWait
+		}
+
+	default:
+		return 0, false
+	}
+
+	if wait > t.maxWait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// rateLimitWait returns how long a rate limited response asks to wait, from Retry-After or, when the
+// quota is used up, X-RateLimit-Reset
+func (t *retryTransport) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), t.now()); ok {
+		return d, true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// One extra second covers clock skew with GitHub
+			wait := time.Unix(reset, 0).Sub(t.now()) + time.Second
+			return max(wait, 0), true
+		}
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// peekBody reads a response body and puts it back so the caller can still read it
+func peekBody(resp *http.Response) string {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// isGraphQLRequest reports whether a request goes to the GitHub GraphQL API
+func isGraphQLRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/graphql")
+}
+
+// isIdempotentRequest reports whether a request can be sent again without side effects
+// GraphQL queries are safe to repeat, mutations are not
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	if !isGraphQLRequest(req) || req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	defer body.Close()
+
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
+
+// isGraphQLRateLimited reports whether a GraphQL response body contains a RATE_LIMITED error
+func isGraphQLRateLimited(body string) bool {
+	if !strings.Contains(body, "RATE_LIMITED") {
+		return false
+	}
+
+	var payload struct {
+		Errors []struct {
+			Type string `json:"type"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return false
+	}
+	for _, e := range payload.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext waits for d or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
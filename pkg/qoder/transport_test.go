@@ -0,0 +1,292 @@
+package qoder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubResponse describes a response returned by the stub transport
+type stubResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+func TestRetryTransport(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resetIn30s := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+	resetIn1h := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		responses      []stubResponse
+		expectedStatus int
+		expectedCalls  int
+		expectedWaits  []time.Duration
+	}{
+		{
+			name:           "success is not retried",
+			method:         http.MethodGet,
+			path:           "/repos/o/r/pulls/1",
+			responses:      []stubResponse{{status: 200}},
+			expectedStatus: 200,
+			expectedCalls:  1,
+		},
+		{
+			name:           "server error on GET is retried with backoff",
+			method:         http.MethodGet,
+			path:           "/repos/o/r/pulls/1",
+			responses:      []stubResponse{{status: 502}, {status: 503}, {status: 200}},
+			expectedStatus: 200,
+			expectedCalls:  3,
+			expectedWaits:  []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:           "server error on POST is not retried",
+			method:         http.MethodPost,
+			path:           "/repos/o/r/pulls/1/reviews",
+			body:           `{"event":"COMMENT"}`,
+			responses:      []stubResponse{{status: 502}, {status: 200}},
+			expectedStatus: 502,
+			expectedCalls:  1,
+		},
+		{
+			name:           "server error on GraphQL query is retried",
+			method:         http.MethodPost,
+			path:           "/graphql",
+			body:           `{"query":"query{viewer{login}}"}`,
+			responses:      []stubResponse{{status: 502}, {status: 200}},
+			expectedStatus: 200,
+			expectedCalls:  2,
+			expectedWaits:  []time.Duration{time.Second},
+		},
+		{
+			name:           "server error on GraphQL mutation is not retried",
+			method:         http.MethodPost,
+			path:           "/graphql",
+			body:           `{"query":"mutation($input:ResolveReviewThreadInput!){resolveReviewThread(input:$input){thread{id}}}"}`,
+			responses:      []stubResponse{{status: 502}, {status: 200}},
+			expectedStatus: 502,
+			expectedCalls:  1,
+		},
+		{
+			name:   "secondary rate limit honors Retry-After even for writes",
+			method: http.MethodPost,
+			path:   "/repos/o/r/pulls/1/reviews",
+			body:   `{"event":"COMMENT"}`,
+			responses: []stubResponse{
+				{status: 403, headers: map[string]string{"Retry-After": "5"}, body: `{"message":"You have exceeded a secondary rate limit"}`},
+				{status: 200},
+			},
+			expectedStatus: 200,
+			expectedCalls:  2,
+			expectedWaits:  []time.Duration{5 * time.Second},
+		},
+		{
+			name:   "secondary rate limit without Retry-After waits a minute",
+			method: http.MethodGet,
+			path:   "/repos/o/r/pulls/1",
+			responses: []stubResponse{
+				{status: 403, body: `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes"}`},
+				{status: 200},
+			},
+			expectedStatus: 200,
+			expectedCalls:  2,
+			expectedWaits:  []time.Duration{time.Minute},
+		},
+		{
+			name:   "primary rate limit waits for reset",
+			method: http.MethodGet,
+			path:   "/repos/o/r/pulls/1",
+			responses: []stubResponse{
+				{status: 403, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Limit": "5000", "X-RateLimit-Reset": resetIn30s}},
+				{status: 200},
+			},
+			expectedStatus: 200,
+			expectedCalls:  2,
+			expectedWaits:  []time.Duration{31 * time.Second},
+		},
+		{
+			name:   "primary rate limit with a long reset is returned",
+			method: http.MethodGet,
+			path:   "/repos/o/r/pulls/1",
+			responses: []stubResponse{
+				{status: 403, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Limit": "5000", "X-RateLimit-Reset": resetIn1h}},
+			},
+			expectedStatus: 403,
+			expectedCalls:  1,
+		},
+		{
+			name:           "permission error is not retried",
+			method:         http.MethodGet,
+			path:           "/repos/o/r/pulls/1",
+			responses:      []stubResponse{{status: 403, body: `{"message":"Resource not accessible by integration"}`}},
+			expectedStatus: 403,
+			expectedCalls:  1,
+		},
+		{
+			name:   "GraphQL RATE_LIMITED error is retried",
+			method: http.MethodPost,
+			path:   "/graphql",
+			body:   `{"query":"query{viewer{login}}"}`,
+			responses: []stubResponse{
+				{status: 200, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": resetIn30s}, body: `{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`},
+				{status: 200, body: `{"data":{"viewer":{"login":"qoder"}}}`},
+			},
+			expectedStatus: 200,
+			expectedCalls:  2,
+			expectedWaits:  []time.Duration{31 * time.Second},
+		},
+		{
+			name:           "gives up after the maximum retries",
+			method:         http.MethodGet,
+			path:           "/repos/o/r/pulls/1",
+			responses:      []stubResponse{{status: 500}, {status: 500}, {status: 500}, {status: 500}, {status: 200}},
+			expectedStatus: 500,
+			expectedCalls:  4,
+			expectedWaits:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					body, _ := io.ReadAll(req.Body)
+					if string(body) != tc.body {
+						t.Errorf("call %d: request body %q, expected %q", calls+1, body, tc.body)
+					}
+				}
+
+				stub := tc.responses[calls]
+				calls++
+				resp := &http.Response{
+					StatusCode: stub.status,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(stub.body)),
+					Request:    req,
+				}
+				for key, value := range stub.headers {
+					resp.Header.Set(key, value)
+				}
+				return resp, nil
+			})
+
+			var waits []time.Duration
+			transport := newRetryTransport(base, newRateLimitTracker(), defaultMaxRetries, defaultMaxRetryWait)
+			transport.now = func() time.Time { return now }
+			transport.sleep = func(_ context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req, _ := http.NewRequest(tc.method, "https://api.github.com"+tc.path, body)
+
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tc.expectedStatus)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("calls = %d, expected %d", calls, tc.expectedCalls)
+			}
+			if len(waits) != len(tc.expectedWaits) {
+				t.Fatalf("waits = %v, expected %v", waits, tc.expectedWaits)
+			}
+			for i := range waits {
+				if waits[i] != tc.expectedWaits[i] {
+					t.Errorf("wait %d = %s, expected %s", i, waits[i], tc.expectedWaits[i])
+				}
+			}
+
+			// The returned body must still be readable
+			if _, err := io.ReadAll(resp.Body); err != nil {
+				t.Errorf("failed to read response body: %v", err)
+			}
+		})
+	}
+}
+
+func TestRateLimitTracker(t *testing.T) {
+	tracker := newRateLimitTracker()
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Limit", "5000")
+	resp.Header.Set("X-RateLimit-Remaining", "120")
+	resp.Header.Set("X-RateLimit-Reset", "1714564800")
+	tracker.update(resp)
+
+	resp = &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Limit", "5000")
+	resp.Header.Set("X-RateLimit-Remaining", "4000")
+	resp.Header.Set("X-RateLimit-Resource", "graphql")
+	tracker.update(resp)
+
+	// Responses without quota headers are ignored
+	tracker.update(&http.Response{Header: http.Header{}})
+
+	statuses := tracker.snapshot()
+	if len(statuses) != 2 {
+		t.Fatalf("got %d statuses, expected 2", len(statuses))
+	}
+	if statuses[0].Resource != "core" || statuses[0].Remaining != 120 {
+		t.Errorf("unexpected core status %+v", statuses[0])
+	}
+	if statuses[1].Resource != "graphql" || statuses[1].Remaining != 4000 {
+		t.Errorf("unexpected graphql status %+v", statuses[1])
+	}
+
+	warning := lowQuotaWarning(statuses)
+	expected := "Note: GitHub API rate limit is running low (core: 120 of 5000 requests left, resets at 12:00:00 UTC). Avoid unnecessary calls."
+	if warning != expected {
+		t.Errorf("lowQuotaWarning() = %q, expected %q", warning, expected)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		value      string
+		expected   time.Duration
+		expectedOK bool
+	}{
+		{name: "seconds", value: "30", expected: 30 * time.Second, expectedOK: true},
+		{name: "HTTP date", value: "Wed, 01 May 2024 12:00:10 GMT", expected: 10 * time.Second, expectedOK: true},
+		{name: "date in the past", value: "Wed, 01 May 2024 11:00:00 GMT", expected: 0, expectedOK: true},
+		{name: "empty", value: "", expectedOK: false},
+		{name: "invalid", value: "soon", expectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value, now)
+			if ok != tc.expectedOK || got != tc.expected {
+				t.Errorf("parseRetryAfter(%q) = %s, %v, expected %s, %v", tc.value, got, ok, tc.expected, tc.expectedOK)
+			}
+		})
+	}
+}